@@ -0,0 +1,252 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// Config holds all settings of the server. Values are read from an optional
+// JSON file (CONFIG_FILE, default config.json) and can be overridden by
+// environment variables.
+type Config struct {
+	DatabaseDSN    string     `json:"databaseDsn"`
+	JWTSecret      string     `json:"jwtSecret"`
+	ListenAddr     string     `json:"listenAddr"`
+	AppURL         string     `json:"appUrl"`
+	CORSOrigins    []string   `json:"corsOrigins"`
+	TrustedProxies []string   `json:"trustedProxies"`
+	FontPath       string     `json:"fontPath"`
+	FontBoldPath   string     `json:"fontBoldPath"`
+	LogoPath       string     `json:"logoPath"`
+	Mail           MailConfig `json:"mail"`
+	OIDC           OIDCConfig `json:"oidc"`
+}
+
+type MailConfig struct {
+	Host     string `json:"host"`
+	Port     string `json:"port"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	From     string `json:"from"`
+}
+
+const (
+	defaultConfigFile   = "config.json"
+	minJWTSecretLength  = 32
+	defaultListenAddr   = "localhost:8080"
+	defaultAppURL       = "https://ministranten.dynv6.net:33333/"
+	defaultFontPath     = "ressources/arial-unicode-ms.ttf"
+	defaultFontBoldPath = "ressources/arial-unicode-ms-bold.ttf"
+	defaultLogoPath     = "ressources/logoRemBG.png"
+	defaultMailPort     = "25"
+)
+
+// OIDCConfig enables the login through an OpenID Connect provider. It is
+// off as long as no issuer is set.
+type OIDCConfig struct {
+	IssuerURL    string `json:"issuerUrl"`
+	ClientID     string `json:"clientId"`
+	ClientSecret string `json:"clientSecret"`
+	RedirectURL  string `json:"redirectUrl"`
+	UIURL        string `json:"uiUrl"`
+}
+
+func (cfg OIDCConfig) Enabled() bool {
+	return cfg.IssuerURL != ""
+}
+
+// Load reads and validates the configuration. All problems are returned
+// together so a broken setup can be fixed in one go.
+func Load() (Config, error) {
+	cfg := Config{
+		ListenAddr:   defaultListenAddr,
+		AppURL:       defaultAppURL,
+		FontPath:     defaultFontPath,
+		FontBoldPath: defaultFontBoldPath,
+		LogoPath:     defaultLogoPath,
+	}
+
+	if err := cfg.readFile(); err != nil {
+		return Config{}, err
+	}
+	cfg.readEnv()
+
+	if err := cfg.validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	return cfg, nil
+}
+
+func (cfg *Config) readFile() error {
+	path, explicit := os.LookupEnv("CONFIG_FILE")
+	if !explicit {
+		path = defaultConfigFile
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && !explicit {
+			return nil
+		}
+		return fmt.Errorf("read config file %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+func (cfg *Config) readEnv() {
+	setFromEnv(&cfg.DatabaseDSN, "DB_DSN")
+	setFromEnv(&cfg.JWTSecret, "JWT_SECRET")
+	setFromEnv(&cfg.ListenAddr, "LISTEN_ADDR")
+	setFromEnv(&cfg.AppURL, "APP_URL")
+	setFromEnv(&cfg.FontPath, "PDF_FONT")
+	setFromEnv(&cfg.FontBoldPath, "PDF_FONT_BOLD")
+	setFromEnv(&cfg.LogoPath, "PDF_LOGO")
+	setFromEnv(&cfg.Mail.Host, "MAIL_HOST")
+	setFromEnv(&cfg.Mail.Port, "MAIL_PORT")
+	setFromEnv(&cfg.Mail.Username, "MAIL_USERNAME")
+	setFromEnv(&cfg.Mail.Password, "MAIL_PASSWORD")
+	setFromEnv(&cfg.Mail.From, "MAIL_FROM")
+	setFromEnv(&cfg.OIDC.IssuerURL, "OIDC_ISSUER")
+	setFromEnv(&cfg.OIDC.ClientID, "OIDC_CLIENT_ID")
+	setFromEnv(&cfg.OIDC.ClientSecret, "OIDC_CLIENT_SECRET")
+	setFromEnv(&cfg.OIDC.RedirectURL, "OIDC_REDIRECT_URL")
+	setFromEnv(&cfg.OIDC.UIURL, "OIDC_UI_URL")
+
+	setListFromEnv(&cfg.CORSOrigins, "CORS_ORIGINS")
+	setListFromEnv(&cfg.TrustedProxies, "TRUSTED_PROXIES")
+}
+
+func setFromEnv(target *string, name string) {
+	if value, ok := os.LookupEnv(name); ok {
+		*target = strings.TrimSpace(value)
+	}
+}
+
+// setListFromEnv reads a comma separated list and skips empty entries.
+func setListFromEnv(target *[]string, name string) {
+	if value, ok := os.LookupEnv(name); ok {
+		*target = nil
+		for _, entry := range strings.Split(value, ",") {
+			if entry = strings.TrimSpace(entry); entry != "" {
+				*target = append(*target, entry)
+			}
+		}
+	}
+}
+
+func (cfg *Config) validate() error {
+	var errs []error
+
+	if cfg.DatabaseDSN == "" {
+		errs = append(errs, errors.New("DB_DSN is required"))
+	} else if _, err := mysql.ParseDSN(cfg.DatabaseDSN); err != nil {
+		errs = append(errs, fmt.Errorf("DB_DSN is not a valid MySQL DSN: %w", err))
+	}
+
+	if len(cfg.JWTSecret) < minJWTSecretLength {
+		errs = append(errs, fmt.Errorf("JWT_SECRET must be at least %d characters long", minJWTSecretLength))
+	}
+
+	if _, port, err := net.SplitHostPort(cfg.ListenAddr); err != nil {
+		errs = append(errs, fmt.Errorf("LISTEN_ADDR %q is not a host:port address", cfg.ListenAddr))
+	} else if !validPort(port) {
+		errs = append(errs, fmt.Errorf("LISTEN_ADDR %q has an invalid port", cfg.ListenAddr))
+	}
+
+	// links in mails append the route of the web app, e.g. #/reset
+	if !validHTTPURL(cfg.AppURL) {
+		errs = append(errs, fmt.Errorf("APP_URL %q is not a valid URL", cfg.AppURL))
+	} else if !strings.HasSuffix(cfg.AppURL, "/") {
+		cfg.AppURL += "/"
+	}
+
+	if len(cfg.CORSOrigins) == 0 {
+		cfg.CORSOrigins = []string{"*"}
+	}
+	for _, origin := range cfg.CORSOrigins {
+		if origin == "*" {
+			if len(cfg.CORSOrigins) > 1 {
+				errs = append(errs, errors.New("CORS_ORIGINS must not combine * with other origins"))
+			}
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			errs = append(errs, fmt.Errorf("CORS_ORIGINS entry %q must look like https://example.org", origin))
+		}
+	}
+
+	// only requests from these addresses may set the client IP via X-Real-IP
+	for _, proxy := range cfg.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				errs = append(errs, fmt.Errorf("TRUSTED_PROXIES entry %q is not an IP address or CIDR range", proxy))
+			}
+		}
+	}
+
+	for _, file := range []struct{ name, path string }{
+		{"PDF_FONT", cfg.FontPath},
+		{"PDF_FONT_BOLD", cfg.FontBoldPath},
+		{"PDF_LOGO", cfg.LogoPath},
+	} {
+		if info, err := os.Stat(file.path); err != nil || info.IsDir() {
+			errs = append(errs, fmt.Errorf("%s file %q not found", file.name, file.path))
+		}
+	}
+
+	if cfg.Mail.Host != "" {
+		if cfg.Mail.Port == "" {
+			cfg.Mail.Port = defaultMailPort
+		}
+		if !validPort(cfg.Mail.Port) {
+			errs = append(errs, fmt.Errorf("MAIL_PORT %q is not a valid port", cfg.Mail.Port))
+		}
+		if cfg.Mail.From == "" {
+			errs = append(errs, errors.New("MAIL_FROM is required when MAIL_HOST is set"))
+		}
+	}
+
+	if cfg.OIDC.Enabled() {
+		if !validHTTPURL(cfg.OIDC.IssuerURL) {
+			errs = append(errs, fmt.Errorf("OIDC_ISSUER %q is not a valid URL", cfg.OIDC.IssuerURL))
+		}
+		if cfg.OIDC.ClientID == "" {
+			errs = append(errs, errors.New("OIDC_CLIENT_ID is required when OIDC_ISSUER is set"))
+		}
+		if !validHTTPURL(cfg.OIDC.RedirectURL) {
+			errs = append(errs, errors.New("OIDC_REDIRECT_URL must be the full URL of /oidc/callback when OIDC_ISSUER is set"))
+		}
+		if cfg.OIDC.UIURL != "" && !validHTTPURL(cfg.OIDC.UIURL) {
+			errs = append(errs, fmt.Errorf("OIDC_UI_URL %q is not a valid URL", cfg.OIDC.UIURL))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func validHTTPURL(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func validPort(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && n > 0 && n <= 65535
+}
+
+// AllowsAllOrigins reports whether CORS is open for every origin.
+func (cfg Config) AllowsAllOrigins() bool {
+	return len(cfg.CORSOrigins) == 1 && cfg.CORSOrigins[0] == "*"
+}
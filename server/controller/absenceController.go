@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	. "minisAPI/models"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+)
+
+func GetAbsences(userId string) []Absence {
+	results := ExecuteSQL(`SELECT id, DATE_FORMAT(date_from, '%Y-%m-%d'), DATE_FORMAT(date_to, '%Y-%m-%d'), IFNULL(reason, '')
+		FROM absence WHERE user_id = ? ORDER BY date_from`, userId)
+	list := []Absence{}
+	for results.Next() {
+		var absence Absence
+		results.Scan(&absence.Id, &absence.DateFrom, &absence.DateTo, &absence.Reason)
+		list = append(list, absence)
+	}
+	return list
+}
+
+// ValidateAbsences checks all new periods of an update before anything is
+// written.
+func ValidateAbsences(update AbsenceUpdate) error {
+	for _, absence := range update.Add {
+		if err := validateAbsence(absence); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdateAbsences adds and removes several absence periods of a user at once in
+// one transaction. Unless direct is set, changes that touch the period of a
+// survey whose deadline has passed are stored as requests for an admin
+// instead; their number is returned as pending.
+func UpdateAbsences(userId string, update AbsenceUpdate, direct bool) ([]int, int, error) {
+	if err := ValidateAbsences(update); err != nil {
+		return nil, 0, err
+	}
+
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("begin txn: %w", err)
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			if rerr := tx.Rollback(); rerr != nil && rerr != sql.ErrTxDone {
+				log.Printf("rollback failed: %v", rerr)
+			}
+		}
+	}()
+
+	pending := 0
+	for _, id := range update.Remove {
+		if !direct {
+			var dateFrom, dateTo string
+			err := tx.QueryRowContext(ctx, "SELECT DATE_FORMAT(date_from, '%Y-%m-%d'), DATE_FORMAT(date_to, '%Y-%m-%d') FROM absence WHERE id = ? AND user_id = ?",
+				id, userId).Scan(&dateFrom, &dateTo)
+			if err != nil {
+				continue
+			}
+			if surveyId, locked := lockedSurveyFor(dateFrom, dateTo); locked {
+				if _, err := tx.ExecContext(ctx, `INSERT INTO ban_change_request (user_id, survey_id, kind, add_change, date_from, date_to, absence_id)
+					VALUES (?, ?, 'absence', 0, ?, ?, ?)`, userId, surveyId, dateFrom, dateTo, id); err != nil {
+					return nil, 0, fmt.Errorf("request removal of absence %d: %w", id, err)
+				}
+				pending++
+				continue
+			}
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM absence WHERE id = ? AND user_id = ?", id, userId); err != nil {
+			return nil, 0, fmt.Errorf("remove absence %d: %w", id, err)
+		}
+	}
+
+	ids := []int{}
+	for _, absence := range update.Add {
+		if !direct {
+			if surveyId, locked := lockedSurveyFor(absence.DateFrom, absence.DateTo); locked {
+				if _, err := tx.ExecContext(ctx, `INSERT INTO ban_change_request (user_id, survey_id, kind, add_change, date_from, date_to, reason)
+					VALUES (?, ?, 'absence', 1, ?, ?, NULLIF(?, ''))`, userId, surveyId, absence.DateFrom, absence.DateTo, absence.Reason); err != nil {
+					return nil, 0, fmt.Errorf("request absence: %w", err)
+				}
+				pending++
+				continue
+			}
+		}
+		result, err := tx.ExecContext(ctx, "INSERT INTO absence (user_id, date_from, date_to, reason) VALUES (?, ?, ?, NULLIF(?, ''))",
+			userId, absence.DateFrom, absence.DateTo, absence.Reason)
+		if err != nil {
+			return nil, 0, fmt.Errorf("add absence: %w", err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return nil, 0, fmt.Errorf("add absence: %w", err)
+		}
+		ids = append(ids, int(id))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, 0, fmt.Errorf("commit tx: %w", err)
+	}
+	committed = true
+	return ids, pending, nil
+}
+
+func validateAbsence(absence Absence) error {
+	from, err := time.Parse("2006-01-02", absence.DateFrom)
+	if err != nil {
+		return fmt.Errorf("Ungültiges Startdatum %q", absence.DateFrom)
+	}
+	to, err := time.Parse("2006-01-02", absence.DateTo)
+	if err != nil {
+		return fmt.Errorf("Ungültiges Enddatum %q", absence.DateTo)
+	}
+	if to.Before(from) {
+		return errors.New("Das Enddatum liegt vor dem Startdatum")
+	}
+	return nil
+}
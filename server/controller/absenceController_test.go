@@ -0,0 +1,38 @@
+package controller
+
+import (
+	"errors"
+	. "minisAPI/models"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestUpdateAbsencesReturnsInsertError(t *testing.T) {
+	mock := withMockDB(t)
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM absence WHERE id = \? AND user_id = \?`).
+		WithArgs(4, "7").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO absence`).
+		WithArgs("7", "2026-11-02", "2026-11-08", "").
+		WillReturnError(errors.New("connection lost"))
+	mock.ExpectRollback()
+
+	update := AbsenceUpdate{
+		Add:    []Absence{{DateFrom: "2026-11-02", DateTo: "2026-11-08"}},
+		Remove: []int{4},
+	}
+	if _, _, err := UpdateAbsences("7", update, true); err == nil {
+		t.Error("failed insert was not reported")
+	}
+}
+
+func TestUpdateAbsencesRejectsInvalidPeriod(t *testing.T) {
+	withMockDB(t)
+
+	update := AbsenceUpdate{Add: []Absence{{DateFrom: "2026-11-08", DateTo: "2026-11-02"}}}
+	if _, _, err := UpdateAbsences("7", update, true); err == nil {
+		t.Error("period ending before it starts was accepted")
+	}
+}
@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"errors"
+	"log"
+	. "minisAPI/models"
+	"slices"
+	"strings"
+	"time"
+)
+
+var ErrInvalidApiKey = errors.New("invalid or expired api key")
+
+// CreateApiKey stores a new key and returns it in plain text. Only its hash
+// is kept, so the key can be shown to the admin this one time.
+func CreateApiKey(create ApiKeyCreate, createdBy int) (int, string, error) {
+	create.Name = strings.TrimSpace(create.Name)
+	if create.Name == "" {
+		return 0, "", errors.New("Bitte gib einen Namen für den Schlüssel an")
+	}
+	if len(create.Scopes) == 0 {
+		return 0, "", errors.New("Bitte wähle mindestens eine Berechtigung aus")
+	}
+	for _, scope := range create.Scopes {
+		if !slices.Contains(AllApiKeyScopes, scope) {
+			return 0, "", errors.New("Unbekannte Berechtigung: " + scope)
+		}
+	}
+	if create.ExpiresAt != "" {
+		expiresAt, err := time.Parse("2006-01-02", create.ExpiresAt)
+		if err != nil {
+			return 0, "", errors.New("Ungültiges Ablaufdatum")
+		}
+		if !expiresAt.After(time.Now()) {
+			return 0, "", errors.New("Das Ablaufdatum muss in der Zukunft liegen")
+		}
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		return 0, "", err
+	}
+	key := ApiKeyPrefix + token
+
+	result, err := db.Exec("INSERT INTO api_key (name, key_prefix, key_hash, created_by, expires_at) VALUES (?, ?, ?, ?, NULLIF(?, ''))",
+		create.Name, key[:12], hashToken(key), createdBy, create.ExpiresAt)
+	if err != nil {
+		return 0, "", err
+	}
+	id, _ := result.LastInsertId()
+	for _, scope := range slices.Compact(slices.Sorted(slices.Values(create.Scopes))) {
+		ExecuteDDL("INSERT INTO api_key_scope (api_key_id, scope) VALUES (?, ?)", id, scope)
+	}
+	log.Printf("api key %d (%s) created by user %d", id, create.Name, createdBy)
+	return int(id), key, nil
+}
+
+func GetApiKeys() []ApiKey {
+	results := ExecuteSQL(`SELECT k.id, k.name, k.key_prefix, IFNULL(CONCAT(u.firstname, ' ', u.lastname), ''), k.created_at,
+		DATE_FORMAT(k.expires_at, '%Y-%m-%d'), k.last_used_at, k.revoked_at
+		FROM api_key k
+		LEFT JOIN user u ON u.id = k.created_by
+		ORDER BY k.revoked_at IS NOT NULL, k.name`)
+	keys := []ApiKey{}
+	for results.Next() {
+		var key ApiKey
+		results.Scan(&key.Id, &key.Name, &key.Prefix, &key.CreatedBy, &key.CreatedAt, &key.ExpiresAt, &key.LastUsedAt, &key.RevokedAt)
+		keys = append(keys, key)
+	}
+	for i := range keys {
+		keys[i].Scopes = getApiKeyScopes(keys[i].Id)
+	}
+	return keys
+}
+
+func getApiKeyScopes(apiKeyId int) []string {
+	results := ExecuteSQL("SELECT scope FROM api_key_scope WHERE api_key_id = ? ORDER BY scope", apiKeyId)
+	scopes := []string{}
+	for results.Next() {
+		var scope string
+		results.Scan(&scope)
+		scopes = append(scopes, scope)
+	}
+	return scopes
+}
+
+func RevokeApiKey(apiKeyId string) bool {
+	result := ExecuteDDL("UPDATE api_key SET revoked_at = NOW() WHERE id = ? AND revoked_at IS NULL", apiKeyId)
+	if result == nil {
+		return false
+	}
+	count, _ := result.RowsAffected()
+	return count > 0
+}
+
+// ResolveApiKey returns the principal of a valid key and records its use.
+// Keys expire at the end of their expiry date.
+func ResolveApiKey(key string) (Principal, error) {
+	var apiKeyId int
+	err := ExecuteSQLRow(`SELECT id FROM api_key
+		WHERE key_hash = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at >= CURDATE())`,
+		hashToken(key)).Scan(&apiKeyId)
+	if err != nil {
+		return Principal{}, ErrInvalidApiKey
+	}
+
+	// one write per minute is enough to see whether a key is still in use
+	ExecuteDDL("UPDATE api_key SET last_used_at = NOW() WHERE id = ? AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL 1 MINUTE)", apiKeyId)
+	return Principal{ApiKeyId: apiKeyId, Permissions: getApiKeyScopes(apiKeyId)}, nil
+}
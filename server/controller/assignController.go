@@ -24,10 +24,16 @@ AssignUsersToEvent
 
 Ranking & weights (exposed here as constants for easy tuning):
 - baseScore = 1.0 for all active/eligible users
-- fairnessWeight = 2.5 (high importance) -> scales with log(daysSinceLastAssignment+1)
+- fairnessWeight = 1.8 (high importance) -> shrinks with the weighted number of attended services
+  within fairnessWindowDays before the event date; the days since the last service break ties
 - preferenceWeight = 2.0 (high importance) -> applied when preferred partner is already selected
 - incenseWeight = 0.5 (medium/low) -> small boost when event requires/incense incentive
 - If user is excluded by ban or weekday or inactive -> they are ineligible (score 0)
+- If the event category requires more experience than the user has -> ineligible (score 0)
+
+Event categories: the service weight of a category decides how much a service counts for
+fairness, the same way the weighted services of the attendance statistics are counted.
+Services with weight 0 (rehearsals) are ignored, services with weight 2 (feast days) count twice.
 
 Note: The algorithm selects deterministically the highest-score user each iteration (greedy).
 */
@@ -38,19 +44,23 @@ type AssignEvent struct {
 	DateBegin     time.Time // date only (time zeroed)
 	MinimalUser   int
 	IgnoreWeekday int
+	Category      string
+	MinExperience int
 }
 
 type AssignUser struct {
-	ID        int
-	FirstName string
-	LastName  string
-	Active    bool
-	Incense   bool
+	ID         int
+	FirstName  string
+	LastName   string
+	Active     bool
+	Incense    bool
+	Experience int
 	// dynamic fields:
-	LastAssigned *time.Time // nil if never assigned
-	Weekdays     map[string]bool
-	Excluded     bool // true if ban or weekday mismatch or inactive
-	Score        float64
+	LastAssigned     *time.Time // nil if never assigned
+	WeightedServices float64    // sum of the service weights within the fairness window
+	Weekdays         map[string]bool
+	Excluded         bool // true if ban or weekday mismatch or inactive
+	Score            float64
 }
 
 // preference graph: for each user id, list of partner ids they prefer to be together with
@@ -62,8 +72,10 @@ const (
 	fairnessWeight   = 1.8 // high importance
 	preferenceWeight = 6.0 // high importance
 	incenseWeight    = 0.7 // moderate / light influence
-	// a maxDaysSince to avoid extreme values; if someone never assigned, treat as large days
-	neverAssignedDays = 3650 // ~10 years effectively "very long"
+	// services within this many days before or after the event count for fairness
+	fairnessWindowDays = 180
+	// scales the weighted service count so it outweighs preferences between 0 and 1 services
+	fairnessServiceScale = 5.0
 )
 
 // AssignUsersToEvent assigns users to the given eventID using the described rules.
@@ -120,8 +132,8 @@ func AssignUsersToEvent(eventID int, db *sql.DB) error {
 	}
 
 	fmt.Println("7")
-	// 5) Load last assignment date per user (plan)
-	if err := populateLastAssigned(ctx, tx, users); err != nil {
+	// 5) Load last assignment date and weighted services per user (plan)
+	if err := populateServiceHistory(ctx, tx, users, event.DateBegin); err != nil {
 		return fmt.Errorf("populate last assigned: %w", err)
 	}
 
@@ -140,6 +152,11 @@ func AssignUsersToEvent(eventID int, db *sql.DB) error {
 			u.Excluded = true
 			continue
 		}
+		// category check: e.g. weddings only take experienced servers
+		if u.Experience < event.MinExperience {
+			u.Excluded = true
+			continue
+		}
 		// weekday check: user must have eventWeekday in user_weekday table
 		if !u.Weekdays[eventWeekday] && event.IgnoreWeekday == 0 {
 			u.Excluded = true
@@ -257,7 +274,10 @@ func dateOnly(t time.Time) time.Time {
 // loadEvent loads event row by id. Expects tx (transaction) context.
 func loadEvent(ctx context.Context, tx *sql.Tx, eventID int) (*AssignEvent, error) {
 	stmt, err := tx.PrepareContext(ctx,
-		"SELECT id, name, date_begin, minimalUser, ignoreWeekday FROM event WHERE id = ? FOR UPDATE")
+		`SELECT e.id, e.name, e.date_begin, e.minimalUser, e.ignoreWeekday, e.status, e.category, IFNULL(c.min_experience, 0)
+		FROM event e
+		LEFT JOIN event_category c ON c.category = e.category
+		WHERE e.id = ? FOR UPDATE`)
 	if err != nil {
 		return nil, err
 	}
@@ -269,9 +289,12 @@ func loadEvent(ctx context.Context, tx *sql.Tx, eventID int) (*AssignEvent, erro
 		dateStr       sql.NullString // <-- FIX: scan DATE into string
 		minimal       sql.NullInt64
 		ignoreWeekday sql.NullInt64
+		status        string
+		category      string
+		minExperience int
 	)
 
-	err = stmt.QueryRowContext(ctx, eventID).Scan(&id, &name, &dateStr, &minimal, &ignoreWeekday)
+	err = stmt.QueryRowContext(ctx, eventID).Scan(&id, &name, &dateStr, &minimal, &ignoreWeekday, &status, &category, &minExperience)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, fmt.Errorf("event %d not found", eventID)
@@ -279,6 +302,10 @@ func loadEvent(ctx context.Context, tx *sql.Tx, eventID int) (*AssignEvent, erro
 		return nil, err
 	}
 
+	if status == "cancelled" {
+		return nil, fmt.Errorf("event %d is cancelled", eventID)
+	}
+
 	if !dateStr.Valid || dateStr.String == "" {
 		return nil, fmt.Errorf("event %d has no date_begin set", eventID)
 	}
@@ -295,14 +322,16 @@ func loadEvent(ctx context.Context, tx *sql.Tx, eventID int) (*AssignEvent, erro
 		DateBegin:     parsedDate,
 		MinimalUser:   int(minimal.Int64),
 		IgnoreWeekday: int(ignoreWeekday.Int64),
+		Category:      category,
+		MinExperience: minExperience,
 	}
 
 	return ev, nil
 }
 
-// loadActiveUsers returns a slice of pointers to User for all users with active = 1
+// loadActiveUsers returns a slice of pointers to User for all assignable users with active = 1
 func loadActiveUsers(ctx context.Context, tx *sql.Tx) ([]*AssignUser, error) {
-	stmt, err := tx.PrepareContext(ctx, "SELECT id, firstname, lastname, active, COALESCE(incense,0) FROM `user` WHERE active = 1")
+	stmt, err := tx.PrepareContext(ctx, "SELECT id, firstname, lastname, active, COALESCE(incense,0), COALESCE(experience,0) FROM `user` WHERE active = 1 AND "+assignableUsers)
 	if err != nil {
 		return nil, err
 	}
@@ -320,16 +349,18 @@ func loadActiveUsers(ctx context.Context, tx *sql.Tx) ([]*AssignUser, error) {
 		var firstname, lastname sql.NullString
 		var activeInt int
 		var incenseInt int
-		if err := rows.Scan(&id, &firstname, &lastname, &activeInt, &incenseInt); err != nil {
+		var experience int
+		if err := rows.Scan(&id, &firstname, &lastname, &activeInt, &incenseInt, &experience); err != nil {
 			return nil, err
 		}
 		u := &AssignUser{
-			ID:        id,
-			FirstName: firstname.String,
-			LastName:  lastname.String,
-			Active:    activeInt == 1,
-			Incense:   incenseInt == 1,
-			Weekdays:  make(map[string]bool),
+			ID:         id,
+			FirstName:  firstname.String,
+			LastName:   lastname.String,
+			Active:     activeInt == 1,
+			Incense:    incenseInt == 1,
+			Experience: experience,
+			Weekdays:   make(map[string]bool),
 		}
 		users = append(users, u)
 	}
@@ -379,7 +410,8 @@ func populateUserWeekdays(ctx context.Context, tx *sql.Tx, users []*AssignUser)
 	return rows.Err()
 }
 
-// populateBansForDate marks users.Excluded = true if they have a ban on the event date
+// populateBansForDate marks users.Excluded = true if they have a ban on the event date,
+// either as a single ban day or inside an absence period
 func populateBansForDate(ctx context.Context, tx *sql.Tx, users []*AssignUser, date time.Time) error {
 	// Create map userID -> *User
 	userMap := make(map[int]*AssignUser, len(users))
@@ -387,13 +419,16 @@ func populateBansForDate(ctx context.Context, tx *sql.Tx, users []*AssignUser, d
 		userMap[u.ID] = u
 	}
 
-	stmt, err := tx.PrepareContext(ctx, "SELECT user_id FROM ban WHERE ban_date = ?")
+	stmt, err := tx.PrepareContext(ctx, `
+		SELECT user_id FROM ban WHERE ban_date = ?
+		UNION
+		SELECT user_id FROM absence WHERE ? BETWEEN date_from AND date_to`)
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
-	rows, err := stmt.QueryContext(ctx, date.Format("2006-01-02"))
+	rows, err := stmt.QueryContext(ctx, date.Format("2006-01-02"), date.Format("2006-01-02"))
 	if err != nil {
 		return err
 	}
@@ -413,8 +448,11 @@ func populateBansForDate(ctx context.Context, tx *sql.Tx, users []*AssignUser, d
 	return rows.Err()
 }
 
-// populateLastAssigned fills LastAssigned for each user by querying plan table (latest event date)
-func populateLastAssigned(ctx context.Context, tx *sql.Tx, users []*AssignUser) error {
+// populateServiceHistory fills LastAssigned (latest event date) and WeightedServices (sum of the
+// service weights within fairnessWindowDays before eventDate) for each user from the plan table.
+// Like the attendance statistics only past services recorded as present count; future, unrecorded,
+// excused and no-show assignments do not, and neither do events whose category has a weight of 0.
+func populateServiceHistory(ctx context.Context, tx *sql.Tx, users []*AssignUser, eventDate time.Time) error {
 	userMap := make(map[int]*AssignUser, len(users))
 	for _, u := range users {
 		userMap[u.ID] = u
@@ -423,21 +461,29 @@ func populateLastAssigned(ctx context.Context, tx *sql.Tx, users []*AssignUser)
 	// SELECT p.user_id, MAX(e.date_begin) FROM plan p JOIN event e ON p.event_id = e.id WHERE p.user_id IN (...) GROUP BY p.user_id;
 	// For simplicity and portability, fetch joins and filter locally.
 	rows, err := tx.QueryContext(ctx, `
-		SELECT p.user_id, e.date_begin
+		SELECT p.user_id, e.date_begin, `+serviceWeightSQL+`
 		FROM plan p
 		JOIN event e ON p.event_id = e.id
+		LEFT JOIN event_category c ON c.category = e.category
+		WHERE e.status <> 'cancelled'
+		AND `+serviceWeightSQL+` > 0
+		AND e.date_begin <= CURDATE()
+		AND p.attendance = 'present'
 		ORDER BY p.user_id, e.date_begin DESC`)
 	if err != nil {
 		return err
 	}
 	defer rows.Close()
 
-	// We'll keep the first seen (latest) date per user.
+	// We'll keep the first seen (latest) date per user and sum up the weights inside the window.
+	windowFrom := dateOnly(eventDate).AddDate(0, 0, -fairnessWindowDays)
+	windowTo := dateOnly(eventDate)
 	seen := make(map[int]bool)
 	for rows.Next() {
 		var uid sql.NullInt64
 		var dt sql.NullString
-		if err := rows.Scan(&uid, &dt); err != nil {
+		var weight float64
+		if err := rows.Scan(&uid, &dt, &weight); err != nil {
 			fmt.Println(err)
 			return err
 		}
@@ -445,12 +491,16 @@ func populateLastAssigned(ctx context.Context, tx *sql.Tx, users []*AssignUser)
 			continue
 		}
 		id := int(uid.Int64)
-		if seen[id] {
+		u, ok := userMap[id]
+		if !ok {
 			continue
 		}
-		if u, ok := userMap[id]; ok {
-			t, _ := time.Parse("2006-01-02", dt.String)
-			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+		t, _ := time.Parse("2006-01-02", dt.String)
+		t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+		if !t.Before(windowFrom) && !t.After(windowTo) {
+			u.WeightedServices += weight
+		}
+		if !seen[id] {
 			u.LastAssigned = &t
 			seen[id] = true
 		}
@@ -458,9 +508,10 @@ func populateLastAssigned(ctx context.Context, tx *sql.Tx, users []*AssignUser)
 	return rows.Err()
 }
 
-// loadPreferences loads preference_together table into Preferences map
+// loadPreferences loads the accepted pairs of the preference_together table into Preferences map.
+// Pending requests have not been confirmed by the other user and are ignored.
 func loadPreferences(ctx context.Context, tx *sql.Tx) (Preferences, error) {
-	rows, err := tx.QueryContext(ctx, "SELECT user_id_1, user_id_2 FROM preference_together")
+	rows, err := tx.QueryContext(ctx, "SELECT user_id_1, user_id_2 FROM preference_together WHERE status = 'accepted'")
 	if err != nil {
 		return nil, err
 	}
@@ -491,29 +542,21 @@ func computeUserScore(u *AssignUser, prefs Preferences, selected map[int]bool, m
 	}
 	score := baseScore
 
-	// 1) Fairness: higher if user hasn't been assigned recently
-	// We use daysSince = days between today and lastAssigned; log-scale growth to avoid extremes.
+	// 1) Fairness: higher the fewer weighted services the user has within the fairness window.
+	// The days since the last service (capped at the window) only break ties between equal counts.
 	nowDate := dateOnly(eventDate)
 
-	var daysSince float64
-	if u.LastAssigned == nil {
-		daysSince = neverAssignedDays
-	} else {
-		last := dateOnly(*u.LastAssigned)
-
+	daysSince := float64(fairnessWindowDays)
+	if u.LastAssigned != nil {
 		// Zeitdifferenz rein nach Datum
-		d := nowDate.Sub(last)
-		daysSince = math.Abs(math.Floor(d.Hours() / 24))
-
-		/*if daysSince < 0 {
-			daysSince = 0
-		}*/
+		d := nowDate.Sub(dateOnly(*u.LastAssigned))
+		daysSince = math.Min(math.Abs(math.Floor(d.Hours()/24)), fairnessWindowDays)
 	}
 
-	fairnessContribution := fairnessWeight * daysSince
+	fairnessContribution := fairnessWeight * (fairnessServiceScale/(1+u.WeightedServices) + daysSince/fairnessWindowDays)
 	score += fairnessContribution
 
-	fmt.Printf("daysSince: %.4f fairnessContribution %.4f u.LastAssigned %s    ", daysSince, fairnessContribution, u.LastAssigned)
+	fmt.Printf("weightedServices: %.2f daysSince: %.0f fairnessContribution %.4f    ", u.WeightedServices, daysSince, fairnessContribution)
 
 	// 2) Preferences: if any preferred partner already selected, boost
 	if partners, ok := prefs[u.ID]; ok && len(partners) > 0 {
@@ -0,0 +1,38 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestPopulateServiceHistoryCountsAttendedPastServices(t *testing.T) {
+	mock := withMockDB(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`WHERE e.status <> 'cancelled'\s+AND IFNULL\(c.service_weight, 1\) > 0\s+AND e.date_begin <= CURDATE\(\)\s+AND p.attendance = 'present'`).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "date_begin", "weight"}).
+			AddRow(1, "2026-10-04", 1.0).
+			AddRow(1, "2026-06-01", 0.5).
+			AddRow(1, "2025-01-05", 1.0))
+	mock.ExpectRollback()
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	defer tx.Rollback()
+
+	user := &AssignUser{ID: 1}
+	eventDate := time.Date(2026, 10, 25, 0, 0, 0, 0, time.UTC)
+	if err := populateServiceHistory(context.Background(), tx, []*AssignUser{user}, eventDate); err != nil {
+		t.Fatalf("populate: %v", err)
+	}
+	if user.WeightedServices != 1.5 {
+		t.Errorf("weighted services = %v, want 1.5", user.WeightedServices)
+	}
+	if user.LastAssigned == nil || user.LastAssigned.Format("2006-01-02") != "2026-10-04" {
+		t.Errorf("last assigned = %v, want 2026-10-04", user.LastAssigned)
+	}
+}
@@ -0,0 +1,99 @@
+package controller
+
+import (
+	"errors"
+	. "minisAPI/models"
+
+	_ "github.com/go-sql-driver/mysql"
+)
+
+var (
+	ErrAttendanceTooEarly    = errors.New("Die Anwesenheit kann erst ab dem Tag des Termins erfasst werden")
+	ErrAttendanceNotAssigned = errors.New("Diese Person ist für den Termin nicht eingeteilt")
+)
+
+// serviceWeightSQL is how much a service counts, for the statistics and for
+// the fairness of the automatic assignment. Needs event_category joined as c.
+const serviceWeightSQL = "IFNULL(c.service_weight, 1)"
+
+func GetEventAttendance(eventId string) []EventAttendance {
+	results := ExecuteSQL(`SELECT u.id, u.firstname, u.lastname, IFNULL(p.attendance, ''), IFNULL(p.attendance_note, '')
+		FROM plan p
+		INNER JOIN user u ON u.id = p.user_id
+		WHERE p.event_id = ?
+		ORDER BY u.lastname, u.firstname`, eventId)
+	list := []EventAttendance{}
+	for results.Next() {
+		var attendance EventAttendance
+		results.Scan(&attendance.UserId, &attendance.Firstname, &attendance.Lastname, &attendance.Attendance, &attendance.Note)
+		list = append(list, attendance)
+	}
+	return list
+}
+
+// SetAttendance records whether an assigned server showed up. An empty
+// attendance clears a wrong entry again. Unknown events return sql.ErrNoRows,
+// users without an assignment ErrAttendanceNotAssigned.
+func SetAttendance(eventId string, update AttendanceUpdate, recordedBy int) error {
+	switch update.Attendance {
+	case AttendancePresent, AttendanceExcused, AttendanceNoShow, "":
+	default:
+		return errors.New("unknown attendance")
+	}
+
+	var started bool
+	if err := ExecuteSQLRow("SELECT date_begin <= CURDATE() FROM event WHERE id = ?", eventId).Scan(&started); err != nil {
+		return err
+	}
+	if !started {
+		return ErrAttendanceTooEarly
+	}
+
+	// checked up front, MySQL reports no affected rows for unchanged entries
+	var assigned bool
+	ExecuteSQLRow("SELECT COUNT(*) FROM plan WHERE event_id = ? AND user_id = ?", eventId, update.UserId).Scan(&assigned)
+	if !assigned {
+		return ErrAttendanceNotAssigned
+	}
+
+	if _, err := db.Exec(`UPDATE plan SET attendance = NULLIF(?, ''), attendance_note = NULLIF(?, ''), attendance_by = ?, attendance_at = NOW()
+		WHERE event_id = ? AND user_id = ?`, update.Attendance, update.Note, recordedBy, eventId, update.UserId); err != nil {
+		return err
+	}
+	syncAttendancePoints(eventId, update.UserId, recordedBy)
+	return nil
+}
+
+// GetAttendanceStats counts the assignments of past, not cancelled events in
+// the range per user. Weighted services only count attended services, using
+// the service weight of the event category.
+func GetAttendanceStats(from string, to string, userId string) []AttendanceStats {
+	results := ExecuteSQL(`SELECT u.id, u.firstname, u.lastname,
+		COUNT(*),
+		SUM(p.attendance = 'present'),
+		SUM(p.attendance = 'excused'),
+		SUM(p.attendance = 'no_show'),
+		SUM(p.attendance IS NULL),
+		IFNULL(SUM(CASE WHEN p.attendance = 'present' THEN `+serviceWeightSQL+` ELSE 0 END), 0)
+		FROM plan p
+		INNER JOIN event e ON e.id = p.event_id
+		INNER JOIN user u ON u.id = p.user_id
+		LEFT JOIN event_category c ON c.category = e.category
+		WHERE e.date_begin BETWEEN ? AND ?
+		AND e.date_begin <= CURDATE()
+		AND e.status <> 'cancelled'
+		AND (? = '' OR u.id = ?)
+		GROUP BY u.id, u.firstname, u.lastname
+		ORDER BY u.lastname, u.firstname`, from, to, userId, userId)
+	list := []AttendanceStats{}
+	for results.Next() {
+		var stats AttendanceStats
+		results.Scan(&stats.UserId, &stats.Firstname, &stats.Lastname, &stats.Assigned, &stats.Present, &stats.Excused,
+			&stats.NoShow, &stats.Unrecorded, &stats.WeightedServices)
+		if recorded := stats.Present + stats.Excused + stats.NoShow; recorded > 0 {
+			stats.NoShowRate = float64(stats.NoShow) / float64(recorded)
+		}
+		list = append(list, stats)
+	}
+	return list
+}
@@ -1,65 +1,149 @@
 package controller
 
 import (
+	"errors"
+	"math"
 	. "minisAPI/models"
 	"net/http"
+	"strconv"
 	"strings"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
+var jwtSecret []byte
+
+var ErrAccountDeactivated = errors.New("Dein Konto ist deaktiviert")
+
+// SetJWTSecret sets the key that signs and verifies the access tokens.
+func SetJWTSecret(secret string) {
+	jwtSecret = []byte(secret)
+}
+
+// DoLogin checks the credentials and starts a session. Failed attempts are
+// throttled per username and IP; on every failure the request is aborted and
+// no token is returned.
 func DoLogin(login Login, c *gin.Context) AccessToken {
-	var (
-		key []byte
-		t   *jwt.Token
-		s   string
-	)
-
-	var isAllowed bool
-	ExecuteSQLRow("SELECT COUNT(*) FROM user WHERE UPPER(USERNAME)=UPPER(?) AND PASSWORD=?", login.Username, login.Password).Scan(&isAllowed)
+	if wait := LoginLockedFor(login.Username, c.ClientIP()); wait > 0 {
+		seconds := int(math.Ceil(wait.Seconds()))
+		c.Header("Retry-After", strconv.Itoa(seconds))
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Zu viele fehlgeschlagene Anmeldungen, bitte warte etwas", "retryAfter": seconds})
+		return AccessToken{}
+	}
+
+	var userId int
+	var stored string
+	ExecuteSQLRow("SELECT ID, PASSWORD FROM user WHERE UPPER(USERNAME)=UPPER(?)", login.Username).Scan(&userId, &stored)
+	isAllowed := checkPassword(userId, stored, login.Password)
+	recordLogin(userId, c, isAllowed)
 	if !isAllowed {
-		c.AbortWithStatus(http.StatusUnauthorized)
+		recordLoginFailure(login.Username, c.ClientIP())
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Benutzername oder Passwort falsch"})
+		return AccessToken{}
+	}
+	clearLoginFailures(login.Username)
+	if isRegistrationPending(userId) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Dein Konto wurde noch nicht freigegeben"})
+		return AccessToken{}
+	}
+	if isDeactivated(userId) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": ErrAccountDeactivated.Error()})
+		return AccessToken{}
 	}
 
-	user := GetUserForUsername(login.Username)
+	token, err := startSession(GetUser(strconv.Itoa(userId)), c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Anmeldung fehlgeschlagen"})
+		return AccessToken{}
+	}
+	return token
+}
 
-	key = []byte("axJGB96eQbhCOCSlEHe5QJszFo2qHBLP")
-	t = jwt.NewWithClaims(jwt.SigningMethodHS256,
-		jwt.MapClaims{
-			"user":         user.Username,
-			"roleId":       user.RoleId,
-			"userId":       user.Id,
-			"creationTime": time.Now().UnixNano(),
-		})
-	s, _ = t.SignedString(key)
+// isDeactivated reports whether an admin switched the account off. Inactive
+// means the person left the group: they are neither assigned nor allowed to
+// log in, and ResolvePrincipal and RefreshSession reject their sessions too.
+// Someone who only pauses for a while keeps the account active and enters an
+// absence instead, so they can still maintain their own availability.
+func isDeactivated(userId int) bool {
+	var inactive bool
+	ExecuteSQLRow("SELECT COUNT(*) FROM user WHERE id = ? AND active = 0", userId).Scan(&inactive)
+	return inactive
+}
 
-	return AccessToken{AccessToken: s}
+func recordLogin(userId int, c *gin.Context, success bool) {
+	if userId == 0 {
+		return
+	}
+	userAgent := c.Request.UserAgent()
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+	ExecuteDDL("INSERT INTO login_history (user_id, ip, user_agent, success) VALUES (?, ?, ?, ?)", userId, c.ClientIP(), userAgent, success)
 }
 
-func CheckToken(c *gin.Context) UserHead {
-	_, claims := ExtractToken(c)
-	username, _ := claims["user"].(string)
-	var person UserHead
-	ExecuteSQLRow("SELECT CONCAT(FIRSTNAME, ' ', LASTNAME), id, ROLE_ID FROM user WHERE USERNAME=?", username).Scan(&person.Name, &person.Id, &person.RoleId)
+// CheckToken returns name, role and permissions of the logged in user.
+func CheckToken(principal Principal) UserHead {
+	person := UserHead{Id: principal.UserId, RoleId: principal.RoleId, Role: principal.Role, Permissions: principal.Permissions}
+	ExecuteSQLRow("SELECT CONCAT(FIRSTNAME, ' ', LASTNAME) FROM user WHERE id = ?", principal.UserId).Scan(&person.Name)
 	return person
 }
 
-func ExtractToken(c *gin.Context) (bool, jwt.MapClaims) {
+// TokenClaims are the claims of an access token.
+type TokenClaims struct {
+	Username  string `json:"user"`
+	UserId    int    `json:"userId"`
+	RoleId    int    `json:"roleId"`
+	SessionId int    `json:"sid"`
+	jwt.RegisteredClaims
+}
+
+// Validate is called by the jwt parser after the registered claims were
+// checked, so a signed token without user or session is rejected as well.
+func (claims TokenClaims) Validate() error {
+	if claims.UserId <= 0 || claims.SessionId <= 0 {
+		return errors.New("token without user or session")
+	}
+	return nil
+}
+
+// BearerToken returns the token of the Authorization header, which is either
+// an access token or an API key.
+func BearerToken(c *gin.Context) string {
 	h := AuthHeader{}
 	c.ShouldBindHeader(&h)
-	idTokenHeader := strings.Split(h.IDToken, "Bearer ")
-	if len(idTokenHeader) < 2 {
-		return false, nil
+	token, found := strings.CutPrefix(h.IDToken, "Bearer ")
+	if !found {
+		return ""
 	}
-	return parseToken(idTokenHeader[1])
+	return strings.TrimSpace(token)
 }
 
-func parseToken(tokenStr string) (bool, jwt.MapClaims) {
-	claims := jwt.MapClaims{}
-	tkn, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
-		return []byte("axJGB96eQbhCOCSlEHe5QJszFo2qHBLP"), nil
-	})
-	return (err == nil && tkn.Valid), claims
+// ParseAccessToken verifies signature, algorithm and expiry of an access token.
+func ParseAccessToken(tokenStr string) (*TokenClaims, error) {
+	claims := &TokenClaims{}
+	_, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
+		return jwtSecret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired(), jwt.WithIssuedAt())
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// ResolvePrincipal loads the current role and permissions of the token user.
+// It fails when the session was revoked or expired or the user was
+// deactivated in the meantime.
+func ResolvePrincipal(claims *TokenClaims) (Principal, error) {
+	principal := Principal{UserId: claims.UserId, SessionId: claims.SessionId}
+	err := ExecuteSQLRow(`SELECT u.role_id, IFNULL(r.role_key, '') FROM session s
+		INNER JOIN user u ON u.id = s.user_id
+		LEFT JOIN role r ON r.id = u.role_id
+		WHERE s.id = ? AND s.user_id = ? AND s.revoked_at IS NULL AND s.expires_at > NOW() AND u.active = 1`,
+		claims.SessionId, claims.UserId).Scan(&principal.RoleId, &principal.Role)
+	if err != nil {
+		return Principal{}, ErrSessionRevoked
+	}
+	principal.Permissions = GetPermissionsForRole(principal.RoleId)
+	return principal, nil
 }
@@ -0,0 +1,43 @@
+package controller
+
+import (
+	"database/sql"
+	. "minisAPI/models"
+
+	_ "github.com/go-sql-driver/mysql"
+)
+
+const defaultCategory = "mass"
+
+func GetCategories() []EventCategory {
+	results := ExecuteSQL("SELECT category, name, service_weight, min_experience, points FROM event_category ORDER BY name")
+	list := []EventCategory{}
+	for results.Next() {
+		var category EventCategory
+		results.Scan(&category.Category, &category.Name, &category.ServiceWeight, &category.MinExperience, &category.Points)
+		list = append(list, category)
+	}
+	return list
+}
+
+// UpdateCategory changes the rules of a category. The set of categories
+// itself is fixed because the assigner relies on it.
+func UpdateCategory(category string, update EventCategoryUpdate) error {
+	var exists bool
+	ExecuteSQLRow("SELECT COUNT(*) FROM event_category WHERE category = ?", category).Scan(&exists)
+	if !exists {
+		return sql.ErrNoRows
+	}
+
+	_, err := db.Exec(`UPDATE event_category SET name = COALESCE(?, name), service_weight = COALESCE(?, service_weight),
+		min_experience = COALESCE(?, min_experience), points = COALESCE(?, points) WHERE category = ?`,
+		update.Name, update.ServiceWeight, update.MinExperience, update.Points, category)
+	return err
+}
+
+func categoryOrDefault(category string) string {
+	if category == "" {
+		return defaultCategory
+	}
+	return category
+}
@@ -0,0 +1,216 @@
+package controller
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	. "minisAPI/models"
+	"time"
+)
+
+var ErrCopyTargetExists = errors.New("Im Zielzeitraum gibt es diese Termine bereits")
+
+type copySourceEvent struct {
+	ID            int
+	Name          string
+	DateBegin     time.Time
+	TimeBegin     string
+	LocationID    int
+	MinimalUser   int
+	IgnoreWeekday bool
+	TemplateID    sql.NullInt64
+	Category      string
+	Location      string
+	LocationOpen  bool
+}
+
+// CopyEvents copies every event between From and To to the period starting at
+// TargetFrom. The offset has to be a whole number of weeks so that every copy
+// keeps its weekday. Copies are created as drafts. With CopyAssignments set the
+// plan rows are copied as well, but only for users that are still available on
+// the new date; all others are listed in the report. A copy is refused when
+// the target period already contains the same events, e.g. when a week is
+// copied twice.
+func CopyEvents(request EventCopyRequest) (EventCopyReport, error) {
+	report := EventCopyReport{CreatedEventIds: []int{}, DroppedAssignments: []DroppedAssignment{}}
+
+	from, err := time.Parse("2006-01-02", request.From)
+	if err != nil {
+		return report, fmt.Errorf("invalid from date: %w", err)
+	}
+	to, err := time.Parse("2006-01-02", request.To)
+	if err != nil {
+		return report, fmt.Errorf("invalid to date: %w", err)
+	}
+	targetFrom, err := time.Parse("2006-01-02", request.TargetFrom)
+	if err != nil {
+		return report, fmt.Errorf("invalid target date: %w", err)
+	}
+	if to.Before(from) {
+		return report, errors.New("to is before from")
+	}
+
+	offsetDays := int(targetFrom.Sub(from).Hours() / 24)
+	if offsetDays == 0 || offsetDays%7 != 0 {
+		return report, errors.New("target has to be shifted by whole weeks")
+	}
+
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return report, fmt.Errorf("begin txn: %w", err)
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			if rerr := tx.Rollback(); rerr != nil && rerr != sql.ErrTxDone {
+				log.Printf("rollback failed: %v", rerr)
+			}
+		}
+	}()
+
+	events, err := loadCopySourceEvents(ctx, tx, request.From, request.To)
+	if err != nil {
+		return report, fmt.Errorf("load source events: %w", err)
+	}
+
+	existing := 0
+	for _, ev := range events {
+		if !ev.LocationOpen {
+			return report, fmt.Errorf("%w: %s (%s am %s)", ErrLocationRetired, ev.Location, ev.Name, ev.DateBegin.Format("02.01.2006"))
+		}
+
+		var exists bool
+		if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) > 0 FROM event
+			WHERE name = ? AND date_begin = ? AND time_begin = ? AND location_id = ? AND status <> 'cancelled'`,
+			ev.Name, ev.DateBegin.AddDate(0, 0, offsetDays).Format("2006-01-02"), ev.TimeBegin, ev.LocationID).Scan(&exists); err != nil {
+			return report, fmt.Errorf("check target of event %d: %w", ev.ID, err)
+		}
+		if exists {
+			existing++
+		}
+	}
+	if existing > 0 {
+		return report, fmt.Errorf("%w (%d Termine)", ErrCopyTargetExists, existing)
+	}
+
+	for _, ev := range events {
+		targetDate := ev.DateBegin.AddDate(0, 0, offsetDays).Format("2006-01-02")
+
+		result, err := tx.ExecContext(ctx, `
+			INSERT INTO event (name, date_begin, time_begin, location_id, minimalUser, ignoreWeekday, template_id, category, status)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, 'draft')`,
+			ev.Name, targetDate, ev.TimeBegin, ev.LocationID, ev.MinimalUser, ev.IgnoreWeekday, ev.TemplateID, ev.Category)
+		if err != nil {
+			return report, fmt.Errorf("copy event %d: %w", ev.ID, err)
+		}
+		id64, _ := result.LastInsertId()
+		newId := int(id64)
+		report.CreatedEventIds = append(report.CreatedEventIds, newId)
+
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO event_duty (event_id, duty, count) SELECT ?, duty, count FROM event_duty WHERE event_id = ?",
+			newId, ev.ID); err != nil {
+			return report, fmt.Errorf("copy duties of event %d: %w", ev.ID, err)
+		}
+
+		if !request.CopyAssignments {
+			continue
+		}
+
+		if err := copyAssignments(ctx, tx, ev, newId, targetDate, &report); err != nil {
+			return report, fmt.Errorf("copy assignments of event %d: %w", ev.ID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return report, fmt.Errorf("commit tx: %w", err)
+	}
+	committed = true
+	log.Printf("Copied %d events from %s-%s to %s (%d assignments copied, %d dropped)",
+		len(report.CreatedEventIds), request.From, request.To, request.TargetFrom, report.CopiedAssignments, len(report.DroppedAssignments))
+	return report, nil
+}
+
+func loadCopySourceEvents(ctx context.Context, tx *sql.Tx, from string, to string) ([]copySourceEvent, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT e.id, e.name, DATE_FORMAT(e.date_begin, '%Y-%m-%d'), TIME_FORMAT(e.time_begin, '%H:%i:%s'),
+			e.location_id, e.minimalUser, IFNULL(e.ignoreWeekday, 0), e.template_id, e.category, l.name, l.active
+		FROM event e
+		INNER JOIN location l ON l.id = e.location_id
+		WHERE e.date_begin BETWEEN ? AND ? AND e.status <> 'cancelled'
+		ORDER BY e.date_begin, e.time_begin`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []copySourceEvent
+	for rows.Next() {
+		var ev copySourceEvent
+		var dateStr string
+		if err := rows.Scan(&ev.ID, &ev.Name, &dateStr, &ev.TimeBegin, &ev.LocationID, &ev.MinimalUser, &ev.IgnoreWeekday, &ev.TemplateID, &ev.Category, &ev.Location, &ev.LocationOpen); err != nil {
+			return nil, err
+		}
+		ev.DateBegin, err = time.Parse("2006-01-02", dateStr)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, ev)
+	}
+	return events, rows.Err()
+}
+
+func copyAssignments(ctx context.Context, tx *sql.Tx, ev copySourceEvent, newId int, targetDate string, report *EventCopyReport) error {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT u.id, u.firstname, u.lastname
+		FROM plan p
+		INNER JOIN user u ON u.id = p.user_id
+		WHERE p.event_id = ?
+		ORDER BY u.lastname, u.firstname`, ev.ID)
+	if err != nil {
+		return err
+	}
+
+	var users []UserSmall
+	for rows.Next() {
+		var u UserSmall
+		if err := rows.Scan(&u.Id, &u.Firstname, &u.Lastname); err != nil {
+			rows.Close()
+			return err
+		}
+		users = append(users, u)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, u := range users {
+		status, err := getAvailabilityStatus(tx, u.Id, targetDate, ev.IgnoreWeekday, ev.Category)
+		if err != nil {
+			return err
+		}
+		if status != "ok" {
+			report.DroppedAssignments = append(report.DroppedAssignments, DroppedAssignment{
+				SourceEventId: ev.ID,
+				TargetEventId: newId,
+				Date:          targetDate,
+				UserId:        u.Id,
+				Firstname:     u.Firstname,
+				Lastname:      u.Lastname,
+				Status:        status,
+				Reason:        getAvailabilityReason(status),
+			})
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, "INSERT INTO plan (user_id, event_id) VALUES (?, ?)", u.Id, newId); err != nil {
+			return err
+		}
+		report.CopiedAssignments++
+	}
+	return nil
+}
@@ -2,19 +2,25 @@ package controller
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 )
 
 var db *sql.DB
 var err error
 
-func InitDB() {
-	db, err = sql.Open("mysql", "myuser:gnidmewff112@tcp(130.61.10.8:3306)/minis")
+// InitDB opens the connection pool and checks that the database is reachable.
+func InitDB(dsn string) error {
+	db, err = sql.Open("mysql", dsn)
 	if err != nil {
-		panic(err.Error())
+		return fmt.Errorf("open database: %w", err)
 	}
+	if err := db.Ping(); err != nil {
+		return fmt.Errorf("connect to database: %w", err)
+	}
+	return nil
 }
 
 func CloseDB() {
@@ -42,3 +48,9 @@ func ExecuteDDL(statement string, params ...interface{}) sql.Result {
 func GetDB() *sql.DB {
 	return db
 }
+
+// isDuplicateEntry reports whether the statement failed on a unique key.
+func isDuplicateEntry(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1062
+}
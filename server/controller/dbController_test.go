@@ -0,0 +1,27 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// withMockDB replaces the package database with a sqlmock for the test and
+// checks at the end that every expected statement was executed.
+func withMockDB(t *testing.T) sqlmock.Sqlmock {
+	t.Helper()
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	previous := db
+	db = mockDB
+	t.Cleanup(func() {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet database expectations: %v", err)
+		}
+		db = previous
+		mockDB.Close()
+	})
+	return mock
+}
@@ -3,44 +3,50 @@ package controller
 import (
 	"database/sql"
 	. "minisAPI/models"
+	"slices"
 	"time"
 
 	_ "github.com/go-sql-driver/mysql"
 )
 
-func GetEventsForUser(userId string) []Event {
-	statement := `select e.id, e.name as eventName, e.date_begin, e.time_begin, e.location_id, l.name as locationName from event e
+// GetEventsForUser lists the assignments of a user. With onlyPublished set,
+// drafts and cancelled events are hidden, which is what normal servers see.
+func GetEventsForUser(userId string, onlyPublished bool) []Event {
+	statement := `select e.id, e.name as eventName, e.date_begin, e.time_begin, e.location_id, l.name as locationName, e.status, e.category from event e
 	inner join plan p on e.id = p.event_id
 	inner join location l on l.id = e.location_id
 	where p.user_id = ?
+	and (? = 0 or (e.status = 'published' and p.status = 'published'))
 	order by date_begin`
-	results := ExecuteSQL(statement, userId)
+	results := ExecuteSQL(statement, userId, onlyPublished)
 	events := []Event{}
 	for results.Next() {
 		var event Event
-		results.Scan(&event.Id, &event.Name, &event.DateBegin, &event.TimeBegin, &event.LocationID, &event.Location)
+		results.Scan(&event.Id, &event.Name, &event.DateBegin, &event.TimeBegin, &event.LocationID, &event.Location, &event.Status, &event.Category)
 		events = append(events, event)
 	}
 	return events
 }
 
-func GetEventsByDateRange(from string, to string) []PlannedEvent {
+func GetEventsByDateRange(from string, to string, onlyPublished bool) []PlannedEvent {
 	statement := `select e.id, e.name as eventName, e.date_begin, e.time_begin, 
-        e.location_id, l.name as locationName, e.minimalUser
+        e.location_id, l.name as locationName, e.minimalUser, e.status, e.category
         from event e
         inner join location l on l.id = e.location_id
         where date_begin BETWEEN ? AND ?
+        and (? = 0 or e.status = 'published')
         order by date_begin, time_begin`
 
-	results := ExecuteSQL(statement, from, to)
+	results := ExecuteSQL(statement, from, to, onlyPublished)
 	events := []PlannedEvent{}
 
 	for results.Next() {
 		var event PlannedEvent
 		results.Scan(&event.Id, &event.Name, &event.DateBegin, &event.TimeBegin,
-			&event.LocationID, &event.Location, &event.MinimalUser)
+			&event.LocationID, &event.Location, &event.MinimalUser, &event.Status, &event.Category)
 
-		event.AssignedUserIds = getAssignedUsers(event.Id)
+		event.AssignedUserIds = getAssignedUsers(event.Id, onlyPublished)
+		event.Duties = getEventDuties(event.Id)
 
 		events = append(events, event)
 	}
@@ -48,12 +54,22 @@ func GetEventsByDateRange(from string, to string) []PlannedEvent {
 	return events
 }
 
-func AddUserToEvent(eventId string, userId int) {
-	ExecuteDDL(
-		"INSERT INTO plan (user_id, event_id) VALUES (?, ?)",
+// AddUserToEvent assigns a user by hand. The plan row takes over the status
+// of the event, so someone added after publishing sees the service right away.
+// Returns sql.ErrNoRows for an unknown event.
+func AddUserToEvent(eventId string, userId int) error {
+	result, err := db.Exec(
+		"INSERT INTO plan (user_id, event_id, status) SELECT ?, id, IF(status = 'published', 'published', 'draft') FROM event WHERE id = ?",
 		userId,
 		eventId,
 	)
+	if err != nil {
+		return err
+	}
+	if count, _ := result.RowsAffected(); count == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
 }
 
 func RemoveUserFromEvent(eventId string, userId int) {
@@ -64,12 +80,12 @@ func RemoveUserFromEvent(eventId string, userId int) {
 	)
 }
 
-func CreateEvent(ev Event) int {
+func CreateEvent(ev Event) (int, error) {
 	statement := `
-        INSERT INTO event (name, date_begin, time_begin, location_id, minimalUser, ignoreWeekday)
-        VALUES (?, ?, ?, ?, ?, ?)
+        INSERT INTO event (name, date_begin, time_begin, location_id, minimalUser, ignoreWeekday, template_id, category)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?)
     `
-	result := ExecuteDDL(
+	result, err := db.Exec(
 		statement,
 		ev.Name,
 		ev.DateBegin,
@@ -77,31 +93,81 @@ func CreateEvent(ev Event) int {
 		ev.LocationID,
 		ev.MinimalUser,
 		ev.IgnoreWeekday,
+		ev.TemplateId,
+		categoryOrDefault(ev.Category),
 	)
+	if err != nil {
+		return 0, err
+	}
 
 	id, _ := result.LastInsertId()
-	return int(id)
+	return int(id), nil
+}
+
+func getAssignedUsers(eventId int, onlyPublished bool) []int {
+	rows := ExecuteSQL("SELECT user_id FROM plan WHERE event_id = ? AND (? = 0 OR status = 'published')", eventId, onlyPublished)
+
+	list := []int{}
+	for rows.Next() {
+		var userId int
+		rows.Scan(&userId)
+		list = append(list, userId)
+	}
+	return list
+}
+
+// SetEventStatus changes the status of a single event. Publishing an event
+// also publishes all of its assignments.
+func SetEventStatus(eventId string, status string) bool {
+	switch status {
+	case EventStatusDraft, EventStatusPublished, EventStatusCancelled:
+	default:
+		return false
+	}
+
+	ExecuteDDL("UPDATE event SET status = ? WHERE id = ?", status, eventId)
+	if status == EventStatusPublished {
+		ExecuteDDL("UPDATE plan SET status = 'published' WHERE event_id = ?", eventId)
+	}
+	return true
 }
 
-func GetLocations() []Location {
-	results := ExecuteSQL("SELECT id, name FROM location ORDER BY name")
-	list := []Location{}
+// PublishEventsInRange publishes all events that are not cancelled in the given
+// period together with their assignments and records who did it.
+func PublishEventsInRange(from string, to string, userId int) int {
+	ExecuteDDL(`UPDATE plan p
+		INNER JOIN event e ON e.id = p.event_id
+		SET p.status = 'published'
+		WHERE e.date_begin BETWEEN ? AND ? AND e.status <> 'cancelled'`, from, to)
+	result := ExecuteDDL("UPDATE event SET status = 'published' WHERE date_begin BETWEEN ? AND ? AND status = 'draft'", from, to)
+	ExecuteDDL("INSERT INTO plan_publication (date_from, date_to, published_by) VALUES (?, ?, ?)", from, to, userId)
+
+	count, _ := result.RowsAffected()
+	return int(count)
+}
+
+func GetPlanPublications() []PlanPublication {
+	results := ExecuteSQL(`SELECT pp.id, pp.date_from, pp.date_to, IFNULL(CONCAT(u.firstname, ' ', u.lastname), ''), pp.published_at
+		FROM plan_publication pp
+		LEFT JOIN user u ON u.id = pp.published_by
+		ORDER BY pp.published_at DESC`)
+	list := []PlanPublication{}
 	for results.Next() {
-		var loc Location
-		results.Scan(&loc.Id, &loc.Name)
-		list = append(list, loc)
+		var publication PlanPublication
+		results.Scan(&publication.Id, &publication.DateFrom, &publication.DateTo, &publication.PublishedBy, &publication.PublishedAt)
+		list = append(list, publication)
 	}
 	return list
 }
 
-func getAssignedUsers(eventId int) []int {
-	rows := ExecuteSQL("SELECT user_id FROM plan WHERE event_id = ?", eventId)
+func getEventDuties(eventId int) []EventDuty {
+	rows := ExecuteSQL("SELECT duty, count FROM event_duty WHERE event_id = ? ORDER BY duty", eventId)
 
-	list := []int{}
+	list := []EventDuty{}
 	for rows.Next() {
-		var userId int
-		rows.Scan(&userId)
-		list = append(list, userId)
+		var duty EventDuty
+		rows.Scan(&duty.Duty, &duty.Count)
+		list = append(list, duty)
 	}
 	return list
 }
@@ -152,16 +218,19 @@ func GetAssignmentOptionsForEvent(eventId string) (EventAssignmentOptionsRespons
 	var dateBegin string
 	var timeBegin string
 	var ignoreWeekday int
+	var minExperience int
 
 	err := ExecuteSQLRow(`
 		SELECT 
-			id,
-			DATE_FORMAT(date_begin, '%Y-%m-%d'),
-			TIME_FORMAT(time_begin, '%H:%i:%s'),
-			IFNULL(ignoreWeekday, 0)
-		FROM event
-		WHERE id = ?
-	`, eventId).Scan(&id, &dateBegin, &timeBegin, &ignoreWeekday)
+			e.id,
+			DATE_FORMAT(e.date_begin, '%Y-%m-%d'),
+			TIME_FORMAT(e.time_begin, '%H:%i:%s'),
+			IFNULL(e.ignoreWeekday, 0),
+			IFNULL(c.min_experience, 0)
+		FROM event e
+		LEFT JOIN event_category c ON c.category = e.category
+		WHERE e.id = ?
+	`, eventId).Scan(&id, &dateBegin, &timeBegin, &ignoreWeekday, &minExperience)
 
 	if err != nil {
 		return EventAssignmentOptionsResponse{}, err
@@ -179,25 +248,7 @@ func GetAssignmentOptionsForEvent(eventId string) (EventAssignmentOptionsRespons
 			u.id,
 			u.firstname,
 			u.lastname,
-			CASE
-				WHEN IFNULL(u.active, 0) = 0 THEN 'inactive'
-
-				WHEN EXISTS (
-					SELECT 1 
-					FROM ban b
-					WHERE b.user_id = u.id
-					AND b.ban_date = ?
-				) THEN 'banned'
-
-				WHEN ? = 0 AND NOT EXISTS (
-					SELECT 1
-					FROM user_weekday uw
-					WHERE uw.user_id = u.id
-					AND LOWER(TRIM(uw.weekday)) IN (?, ?, ?, ?)
-				) THEN 'weekday_inactive'
-
-				ELSE 'ok'
-			END AS availability_status,
+			`+availabilityStatusSQL+` AS availability_status,
 
 			(
 				SELECT DATEDIFF(?, MAX(e_last.date_begin))
@@ -205,6 +256,8 @@ func GetAssignmentOptionsForEvent(eventId string) (EventAssignmentOptionsRespons
 				INNER JOIN event e_last ON e_last.id = p_last.event_id
 				WHERE p_last.user_id = u.id
 				AND e_last.id <> ?
+				AND e_last.status <> 'cancelled'
+				AND IFNULL(p_last.attendance, 'present') = 'present'
 				AND (
 					TIMESTAMP(e_last.date_begin, e_last.time_begin) < ?
 					OR (
@@ -220,6 +273,7 @@ func GetAssignmentOptionsForEvent(eventId string) (EventAssignmentOptionsRespons
 				INNER JOIN event e_next ON e_next.id = p_next.event_id
 				WHERE p_next.user_id = u.id
 				AND e_next.id <> ?
+				AND e_next.status <> 'cancelled'
 				AND (
 					TIMESTAMP(e_next.date_begin, e_next.time_begin) > ?
 					OR (
@@ -230,35 +284,32 @@ func GetAssignmentOptionsForEvent(eventId string) (EventAssignmentOptionsRespons
 			) AS next_assignment_days_after
 
 		FROM user u
+		WHERE `+assignableUsers+`
 		ORDER BY
 			CASE availability_status
 				WHEN 'ok' THEN 1
 				WHEN 'weekday_inactive' THEN 2
-				WHEN 'banned' THEN 3
-				WHEN 'inactive' THEN 4
-				ELSE 5
+				WHEN 'inexperienced' THEN 3
+				WHEN 'banned' THEN 4
+				WHEN 'inactive' THEN 5
+				ELSE 6
 			END,
 			u.lastname,
 			u.firstname
 	`,
-		dateBegin,
-		ignoreWeekday,
-		weekdayKeys[0],
-		weekdayKeys[1],
-		weekdayKeys[2],
-		weekdayKeys[3],
+		slices.Concat(availabilityStatusArgs(dateBegin, minExperience, ignoreWeekday != 0, weekdayKeys), []any{
+			dateBegin,
+			id,
+			currentDateTime,
+			currentDateTime,
+			id,
 
-		dateBegin,
-		id,
-		currentDateTime,
-		currentDateTime,
-		id,
-
-		dateBegin,
-		id,
-		currentDateTime,
-		currentDateTime,
-		id,
+			dateBegin,
+			id,
+			currentDateTime,
+			currentDateTime,
+			id,
+		})...,
 	)
 
 	defer rows.Close()
@@ -302,12 +353,84 @@ func GetAssignmentOptionsForEvent(eventId string) (EventAssignmentOptionsRespons
 	}, nil
 }
 
+// availabilityStatusSQL decides whether a user (aliased u) can serve on a
+// date. It is shared by the assignment options and getAvailabilityStatus;
+// its parameters come from availabilityStatusArgs.
+const availabilityStatusSQL = `
+			CASE
+				WHEN IFNULL(u.active, 0) = 0 THEN 'inactive'
+
+				WHEN EXISTS (
+					SELECT 1
+					FROM ban b
+					WHERE b.user_id = u.id
+					AND b.ban_date = ?
+				) OR EXISTS (
+					SELECT 1
+					FROM absence a
+					WHERE a.user_id = u.id
+					AND ? BETWEEN a.date_from AND a.date_to
+				) THEN 'banned'
+
+				WHEN IFNULL(u.experience, 0) < ? THEN 'inexperienced'
+
+				WHEN ? = 0 AND NOT EXISTS (
+					SELECT 1
+					FROM user_weekday uw
+					WHERE uw.user_id = u.id
+					AND LOWER(TRIM(uw.weekday)) IN (?, ?, ?, ?)
+				) THEN 'weekday_inactive'
+
+				ELSE 'ok'
+			END`
+
+func availabilityStatusArgs(date string, minExperience int, ignoreWeekday bool, weekdayKeys []string) []any {
+	return []any{
+		date,
+		date,
+		minExperience,
+		ignoreWeekday,
+		weekdayKeys[0],
+		weekdayKeys[1],
+		weekdayKeys[2],
+		weekdayKeys[3],
+	}
+}
+
+// rowQuerier is implemented by *sql.DB and *sql.Tx.
+type rowQuerier interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// getAvailabilityStatus checks a single user for a date with the same rules as
+// GetAssignmentOptionsForEvent and returns one of its availability statuses.
+func getAvailabilityStatus(tx rowQuerier, userId int, date string, ignoreWeekday bool, category string) (string, error) {
+	weekdayKeys, err := getWeekdayKeys(date)
+	if err != nil {
+		return "", err
+	}
+
+	var minExperience int
+	err = tx.QueryRow("SELECT min_experience FROM event_category WHERE category = ?", category).Scan(&minExperience)
+	if err != nil && err != sql.ErrNoRows {
+		return "", err
+	}
+
+	var status string
+	err = tx.QueryRow("SELECT "+availabilityStatusSQL+" FROM user u WHERE u.id = ?",
+		append(availabilityStatusArgs(date, minExperience, ignoreWeekday, weekdayKeys), userId)...,
+	).Scan(&status)
+	return status, err
+}
+
 func getAvailabilityReason(status string) string {
 	switch status {
 	case "inactive":
 		return "Diese Person ist inaktiv"
 	case "banned":
 		return "Diese Person hat an diesem Tag eine Sperrung"
+	case "inexperienced":
+		return "Diese Person hat für diese Art von Termin noch nicht genug Erfahrung"
 	case "weekday_inactive":
 		return "Diese Person hat diesen Wochentag eigentlich nicht aktiv"
 	default:
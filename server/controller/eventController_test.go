@@ -0,0 +1,30 @@
+package controller
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestAddUserToEventCopiesEventStatus(t *testing.T) {
+	mock := withMockDB(t)
+	mock.ExpectExec(`INSERT INTO plan \(user_id, event_id, status\) SELECT \?, id, IF\(status = 'published', 'published', 'draft'\) FROM event WHERE id = \?`).
+		WithArgs(7, "12").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	if err := AddUserToEvent("12", 7); err != nil {
+		t.Fatalf("add user: %v", err)
+	}
+}
+
+func TestAddUserToEventUnknownEvent(t *testing.T) {
+	mock := withMockDB(t)
+	mock.ExpectExec(`INSERT INTO plan`).
+		WithArgs(7, "404").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if err := AddUserToEvent("404", 7); err != sql.ErrNoRows {
+		t.Errorf("err = %v, want sql.ErrNoRows", err)
+	}
+}
@@ -0,0 +1,279 @@
+package controller
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	. "minisAPI/models"
+	"time"
+)
+
+// personalDataTables hold rows that only describe the user, keyed by user_id.
+// EraseUser deletes all of them, so a new table of that kind belongs here.
+var personalDataTables = []string{
+	"ban",
+	"absence",
+	"user_weekday",
+	"login_history",
+	"session",
+	"password_reset",
+	"oidc_identity",
+	"availability_response",
+	"ban_change_request",
+}
+
+// authorColumns reference the user as the author of rows that belong to
+// others. EraseUser keeps the rows and removes the reference.
+var authorColumns = []struct {
+	table  string
+	column string
+}{
+	{"api_key", "created_by"},
+	{"availability_survey", "created_by"},
+	{"ban_change_request", "decided_by"},
+	{"plan", "attendance_by"},
+	{"plan_publication", "published_by"},
+	{"points_entry", "created_by"},
+	{"swap_request", "decided_by"},
+}
+
+// ExportUserData collects every row that is linked to the user.
+func ExportUserData(userId string) (UserDataExport, error) {
+	user := GetUser(userId)
+	if user.Id == 0 {
+		return UserDataExport{}, sql.ErrNoRows
+	}
+
+	export := UserDataExport{
+		ExportedAt:        time.Now().Format(time.RFC3339),
+		User:              user,
+		Assignments:       []ExportedAssignment{},
+		BanDates:          GetBanDates(userId),
+		Absences:          GetAbsences(userId),
+		Weekdays:          GetUserWeekdays(userId),
+		SurveyResponses:   []ExportedSurveyResponse{},
+		BanChangeRequests: []BanChangeRequest{},
+		Preferences:       []ExportedPreference{},
+		Guardians:         []UserSmall{},
+		Children:          GetChildren(userId),
+		Points:            GetUserPoints(userId).Entries,
+		SwapRequests:      []SwapRequest{},
+		LoginHistory:      []ExportedLogin{},
+		Sessions:          []ExportedSession{},
+		PasswordResets:    []ExportedPasswordReset{},
+		OIDCIdentities:    []ExportedOIDCIdentity{},
+	}
+
+	ExecuteSQLRow("SELECT IFNULL(phone, ''), registration_status, IFNULL(registered_at, '') FROM user WHERE id = ?", userId).
+		Scan(&export.Account.Phone, &export.Account.RegistrationStatus, &export.Account.RegisteredAt)
+
+	results := ExecuteSQL(`SELECT e.id, e.name, DATE_FORMAT(e.date_begin, '%Y-%m-%d'), TIME_FORMAT(e.time_begin, '%H:%i:%s'), l.name,
+		p.status, IFNULL(p.attendance, ''), IFNULL(p.attendance_note, '')
+		FROM plan p
+		INNER JOIN event e ON e.id = p.event_id
+		INNER JOIN location l ON l.id = e.location_id
+		WHERE p.user_id = ?
+		ORDER BY e.date_begin, e.time_begin`, userId)
+	for results.Next() {
+		var assignment ExportedAssignment
+		results.Scan(&assignment.EventId, &assignment.EventName, &assignment.DateBegin, &assignment.TimeBegin, &assignment.Location,
+			&assignment.Status, &assignment.Attendance, &assignment.AttendanceNote)
+		export.Assignments = append(export.Assignments, assignment)
+	}
+
+	results = ExecuteSQL(`SELECT r.survey_id, DATE_FORMAT(s.date_from, '%Y-%m-%d'), DATE_FORMAT(s.date_to, '%Y-%m-%d'), r.submitted_at
+		FROM availability_response r
+		INNER JOIN availability_survey s ON s.id = r.survey_id
+		WHERE r.user_id = ?
+		ORDER BY r.submitted_at`, userId)
+	for results.Next() {
+		var response ExportedSurveyResponse
+		results.Scan(&response.SurveyId, &response.DateFrom, &response.DateTo, &response.SubmittedAt)
+		export.SurveyResponses = append(export.SurveyResponses, response)
+	}
+
+	results = ExecuteSQL(`SELECT id, user_id, survey_id, kind, add_change,
+		IFNULL(DATE_FORMAT(date_from, '%Y-%m-%d'), ''), IFNULL(DATE_FORMAT(date_to, '%Y-%m-%d'), ''), IFNULL(reason, ''),
+		absence_id, status, created_at
+		FROM ban_change_request WHERE user_id = ? ORDER BY created_at`, userId)
+	for results.Next() {
+		var request BanChangeRequest
+		results.Scan(&request.Id, &request.UserId, &request.SurveyId, &request.Kind, &request.Add,
+			&request.DateFrom, &request.DateTo, &request.Reason, &request.AbsenceId, &request.Status, &request.CreatedAt)
+		request.Name = user.Firstname + " " + user.Lastname
+		export.BanChangeRequests = append(export.BanChangeRequests, request)
+	}
+
+	results = ExecuteSQL("SELECT user_id_1, user_id_2, status FROM preference_together WHERE user_id_1 = ? OR user_id_2 = ?", userId, userId)
+	for results.Next() {
+		var preference ExportedPreference
+		results.Scan(&preference.UserId1, &preference.UserId2, &preference.Status)
+		export.Preferences = append(export.Preferences, preference)
+	}
+
+	results = ExecuteSQL(`SELECT u.id, u.firstname, u.lastname FROM guardian_child gc
+		INNER JOIN user u ON u.id = gc.guardian_id
+		WHERE gc.child_id = ?`, userId)
+	for results.Next() {
+		var guardian UserSmall
+		results.Scan(&guardian.Id, &guardian.Firstname, &guardian.Lastname)
+		export.Guardians = append(export.Guardians, guardian)
+	}
+
+	results = ExecuteSQL(swapSelect+" WHERE s.from_user_id = ? OR s.to_user_id = ? ORDER BY s.created_at", userId, userId)
+	for results.Next() {
+		export.SwapRequests = append(export.SwapRequests, scanSwapRequest(results))
+	}
+
+	results = ExecuteSQL("SELECT logged_in_at, ip, IFNULL(user_agent, ''), success FROM login_history WHERE user_id = ? ORDER BY logged_in_at", userId)
+	for results.Next() {
+		var login ExportedLogin
+		results.Scan(&login.LoggedInAt, &login.Ip, &login.UserAgent, &login.Success)
+		export.LoginHistory = append(export.LoginHistory, login)
+	}
+
+	results = ExecuteSQL(`SELECT created_at, last_used_at, expires_at, IFNULL(revoked_at, ''), ip, IFNULL(user_agent, '')
+		FROM session WHERE user_id = ? ORDER BY created_at`, userId)
+	for results.Next() {
+		var session ExportedSession
+		results.Scan(&session.CreatedAt, &session.LastUsedAt, &session.ExpiresAt, &session.RevokedAt, &session.Ip, &session.UserAgent)
+		export.Sessions = append(export.Sessions, session)
+	}
+
+	results = ExecuteSQL("SELECT created_at, expires_at, IFNULL(used_at, '') FROM password_reset WHERE user_id = ? ORDER BY created_at", userId)
+	for results.Next() {
+		var reset ExportedPasswordReset
+		results.Scan(&reset.CreatedAt, &reset.ExpiresAt, &reset.UsedAt)
+		export.PasswordResets = append(export.PasswordResets, reset)
+	}
+
+	results = ExecuteSQL("SELECT issuer, subject, created_at FROM oidc_identity WHERE user_id = ? ORDER BY created_at", userId)
+	for results.Next() {
+		var identity ExportedOIDCIdentity
+		results.Scan(&identity.Issuer, &identity.Subject, &identity.CreatedAt)
+		export.OIDCIdentities = append(export.OIDCIdentities, identity)
+	}
+
+	return export, nil
+}
+
+// ExportUserDataZip packs the export into a ZIP with one JSON file per
+// section and the complete export as data.json.
+func ExportUserDataZip(export UserDataExport) ([]byte, error) {
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"data.json", export},
+		{"user.json", export.User},
+		{"account.json", export.Account},
+		{"plan.json", export.Assignments},
+		{"ban.json", export.BanDates},
+		{"absence.json", export.Absences},
+		{"user_weekday.json", export.Weekdays},
+		{"availability_response.json", export.SurveyResponses},
+		{"ban_change_request.json", export.BanChangeRequests},
+		{"preference_together.json", export.Preferences},
+		{"guardian.json", map[string][]UserSmall{"guardians": export.Guardians, "children": export.Children}},
+		{"points.json", export.Points},
+		{"swap_request.json", export.SwapRequests},
+		{"login_history.json", export.LoginHistory},
+		{"session.json", export.Sessions},
+		{"password_reset.json", export.PasswordResets},
+		{"oidc_identity.json", export.OIDCIdentities},
+	}
+
+	buf := new(bytes.Buffer)
+	archive := zip.NewWriter(buf)
+	for _, file := range files {
+		w, err := archive.Create(file.name)
+		if err != nil {
+			return nil, err
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.data); err != nil {
+			return nil, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// EraseUser anonymises a user. Names, login data, availability, preferences
+// and free text are removed, and so is the user as author of other rows; the
+// plan rows and points stay so that counts and statistics of past services
+// remain correct.
+func EraseUser(userId string) error {
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin txn: %w", err)
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			if rerr := tx.Rollback(); rerr != nil && rerr != sql.ErrTxDone {
+				log.Printf("rollback failed: %v", rerr)
+			}
+		}
+	}()
+
+	// failed logins are stored by username, which is gone after anonymising
+	if _, err := tx.ExecContext(ctx, "DELETE FROM login_failure WHERE username = (SELECT username FROM user WHERE id = ?)", userId); err != nil {
+		return fmt.Errorf("delete login failures: %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx, `UPDATE user SET firstname = 'Gelöschte', lastname = CONCAT('Person ', id),
+		username = CONCAT('geloescht-', id), password = '', email = NULL, phone = NULL, active = 0, erased_at = NOW()
+		WHERE id = ? AND erased_at IS NULL`, userId)
+	if err != nil {
+		return fmt.Errorf("anonymise user: %w", err)
+	}
+	if count, _ := result.RowsAffected(); count == 0 {
+		return sql.ErrNoRows
+	}
+
+	for _, table := range personalDataTables {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE user_id = ?", userId); err != nil {
+			return fmt.Errorf("delete %s: %w", table, err)
+		}
+	}
+	for _, author := range authorColumns {
+		if _, err := tx.ExecContext(ctx, "UPDATE "+author.table+" SET "+author.column+" = NULL WHERE "+author.column+" = ?", userId); err != nil {
+			return fmt.Errorf("clear %s.%s: %w", author.table, author.column, err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM preference_together WHERE user_id_1 = ? OR user_id_2 = ?", userId, userId); err != nil {
+		return fmt.Errorf("delete preferences: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM guardian_child WHERE guardian_id = ? OR child_id = ?", userId, userId); err != nil {
+		return fmt.Errorf("delete guardians: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE swap_request SET status = 'cancelled' WHERE (from_user_id = ? OR to_user_id = ?) AND status IN ('open', 'pending_approval')", userId, userId); err != nil {
+		return fmt.Errorf("cancel swap requests: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE plan SET attendance_note = NULL WHERE user_id = ?", userId); err != nil {
+		return fmt.Errorf("clear attendance notes: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE points_entry SET reason = NULL WHERE user_id = ?", userId); err != nil {
+		return fmt.Errorf("clear points reasons: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	committed = true
+	log.Printf("Erased personal data of user %s", userId)
+	return nil
+}
+
+func UserExportFilename(userId string) string {
+	return "Daten-" + userId + "-" + time.Now().Format("2006-01-02")
+}
@@ -0,0 +1,34 @@
+package controller
+
+import (
+	"archive/zip"
+	"bytes"
+	. "minisAPI/models"
+	"testing"
+)
+
+func TestExportUserDataZipContainsAllSections(t *testing.T) {
+	export := UserDataExport{
+		Account:           ExportedAccount{Phone: "0123 456", RegisteredAt: "2026-03-01 10:00:00"},
+		SurveyResponses:   []ExportedSurveyResponse{{SurveyId: 1}},
+		BanChangeRequests: []BanChangeRequest{{Id: 2}},
+	}
+	data, err := ExportUserDataZip(export)
+	if err != nil {
+		t.Fatalf("zip: %v", err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("read zip: %v", err)
+	}
+
+	names := map[string]bool{}
+	for _, file := range archive.File {
+		names[file.Name] = true
+	}
+	for _, want := range []string{"data.json", "account.json", "availability_response.json", "ban_change_request.json"} {
+		if !names[want] {
+			t.Errorf("zip misses %s", want)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package controller
+
+import (
+	. "minisAPI/models"
+	"strconv"
+
+	_ "github.com/go-sql-driver/mysql"
+)
+
+func GetChildren(guardianId string) []UserSmall {
+	results := ExecuteSQL(`SELECT u.id, u.firstname, u.lastname FROM guardian_child gc
+		INNER JOIN user u ON u.id = gc.child_id
+		WHERE gc.guardian_id = ?
+		ORDER BY u.lastname, u.firstname`, guardianId)
+	children := []UserSmall{}
+	for results.Next() {
+		var child UserSmall
+		results.Scan(&child.Id, &child.Firstname, &child.Lastname)
+		children = append(children, child)
+	}
+	return children
+}
+
+func AddChild(guardianId string, childId int) {
+	ExecuteDDL("INSERT INTO guardian_child (guardian_id, child_id) VALUES (?, ?)", guardianId, childId)
+}
+
+func RemoveChild(guardianId string, childId int) {
+	ExecuteDDL("DELETE FROM guardian_child WHERE guardian_id = ? AND child_id = ?", guardianId, childId)
+}
+
+func IsGuardianOf(guardianId int, childId string) bool {
+	var linked bool
+	ExecuteSQLRow("SELECT COUNT(*) FROM guardian_child WHERE guardian_id = ? AND child_id = ?", guardianId, childId).Scan(&linked)
+	return linked
+}
+
+// GetEventsForChildren returns the published assignments of every child that
+// is linked to the guardian, grouped by child.
+func GetEventsForChildren(guardianId int) []ChildEvents {
+	list := []ChildEvents{}
+	for _, child := range GetChildren(strconv.Itoa(guardianId)) {
+		list = append(list, ChildEvents{
+			Child:  child,
+			Events: GetEventsForUser(strconv.Itoa(child.Id), true),
+		})
+	}
+	return list
+}
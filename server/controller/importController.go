@@ -0,0 +1,325 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	. "minisAPI/models"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Column headers of the user export. The import accepts the same headers (and
+// a few English aliases), so an exported file can be edited and read back.
+var userExportHeader = []string{"Vorname", "Nachname", "Benutzername", "Rolle", "Aktiv", "Weihrauch", "Erfahrung", "Wochentage"}
+
+var importColumnAliases = map[string]string{
+	"vorname":      "firstname",
+	"firstname":    "firstname",
+	"nachname":     "lastname",
+	"lastname":     "lastname",
+	"benutzername": "username",
+	"username":     "username",
+	"rolle":        "roleId",
+	"role":         "roleId",
+	"roleid":       "roleId",
+	"aktiv":        "active",
+	"active":       "active",
+	"weihrauch":    "incense",
+	"incense":      "incense",
+	"erfahrung":    "experience",
+	"experience":   "experience",
+	"wochentage":   "weekdays",
+	"weekdays":     "weekdays",
+}
+
+var importWeekdayAliases = map[string]string{
+	"mo": "MON", "mon": "MON", "montag": "MON", "monday": "MON",
+	"di": "TUE", "tue": "TUE", "dienstag": "TUE", "tuesday": "TUE",
+	"mi": "WED", "wed": "WED", "mittwoch": "WED", "wednesday": "WED",
+	"do": "THU", "thu": "THU", "donnerstag": "THU", "thursday": "THU",
+	"fr": "FRI", "fri": "FRI", "freitag": "FRI", "friday": "FRI",
+	"sa": "SAT", "sat": "SAT", "samstag": "SAT", "saturday": "SAT",
+	"so": "SUN", "sun": "SUN", "sonntag": "SUN", "sunday": "SUN",
+}
+
+const defaultImportRoleId = defaultRoleId
+
+// ReadUserSheet reads the first sheet of an XLSX file or a CSV file (comma or
+// semicolon separated) into rows of cells.
+func ReadUserSheet(filename string, data []byte) ([][]string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".xlsx":
+		f, err := excelize.OpenReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		sheets := f.GetSheetList()
+		if len(sheets) == 0 {
+			return nil, errors.New("Die Datei enthält keine Tabelle")
+		}
+		return f.GetRows(sheets[0])
+	case ".csv", "":
+		data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+		reader := csv.NewReader(bytes.NewReader(data))
+		firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+		if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+			reader.Comma = ';'
+		}
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		return reader.ReadAll()
+	default:
+		return nil, fmt.Errorf("Dateityp %q wird nicht unterstützt, bitte XLSX oder CSV verwenden", filepath.Ext(filename))
+	}
+}
+
+// PreviewUserImport validates every row of the sheet and checks it for
+// duplicates against the existing users and the rows above it. Nothing is
+// written to the database.
+func PreviewUserImport(sheet [][]string, callerPermissions []string) (UserImportResult, error) {
+	result := UserImportResult{Rows: []UserImportRow{}}
+	if len(sheet) == 0 {
+		return result, errors.New("Die Datei ist leer")
+	}
+
+	columns := map[string]int{}
+	for i, header := range sheet[0] {
+		if key, ok := importColumnAliases[strings.ToLower(strings.TrimSpace(header))]; ok {
+			columns[key] = i
+		}
+	}
+	if _, ok := columns["firstname"]; !ok {
+		return result, errors.New("Die Spalte Vorname fehlt")
+	}
+	if _, ok := columns["lastname"]; !ok {
+		return result, errors.New("Die Spalte Nachname fehlt")
+	}
+
+	existing := GetAllUser()
+	seenUsernames := map[string]int{}
+	seenNames := map[string]int{}
+
+	for i, record := range sheet[1:] {
+		cell := func(key string) string {
+			index, ok := columns[key]
+			if !ok || index >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[index])
+		}
+
+		if strings.Join(record, "") == "" {
+			continue
+		}
+
+		row := UserImportRow{
+			Row:       i + 2,
+			Firstname: cell("firstname"),
+			Lastname:  cell("lastname"),
+			Username:  cell("username"),
+			RoleId:    defaultImportRoleId,
+			Active:    1,
+			Weekdays:  []string{},
+			Errors:    []string{},
+		}
+
+		if row.Firstname == "" {
+			row.Errors = append(row.Errors, "Vorname fehlt")
+		}
+		if row.Lastname == "" {
+			row.Errors = append(row.Errors, "Nachname fehlt")
+		}
+		if row.Username == "" && row.Firstname != "" && row.Lastname != "" {
+			row.Username = suggestUsername(row.Firstname, row.Lastname)
+		}
+
+		if value := cell("roleId"); value != "" {
+			roleId, err := strconv.Atoi(value)
+			if err != nil || roleId < 1 {
+				row.Errors = append(row.Errors, fmt.Sprintf("Ungültige Rolle %q", value))
+			} else {
+				row.RoleId = roleId
+			}
+		}
+		if err := CheckAssignableRole(row.RoleId, callerPermissions); err != nil {
+			row.Errors = append(row.Errors, fmt.Sprintf("Rolle %d: %s", row.RoleId, err.Error()))
+		}
+		if value := cell("experience"); value != "" {
+			experience, err := strconv.Atoi(value)
+			if err != nil || experience < 0 {
+				row.Errors = append(row.Errors, fmt.Sprintf("Ungültige Erfahrung %q", value))
+			} else {
+				row.Experience = experience
+			}
+		}
+		if value := cell("active"); value != "" {
+			active, ok := parseImportBool(value)
+			if !ok {
+				row.Errors = append(row.Errors, fmt.Sprintf("Ungültiger Wert für Aktiv %q", value))
+			} else if !active {
+				row.Active = 0
+			}
+		}
+		if value := cell("incense"); value != "" {
+			incense, ok := parseImportBool(value)
+			if !ok {
+				row.Errors = append(row.Errors, fmt.Sprintf("Ungültiger Wert für Weihrauch %q", value))
+			} else if incense {
+				row.Incense = 1
+			}
+		}
+		for _, value := range strings.FieldsFunc(cell("weekdays"), func(r rune) bool {
+			return r == ',' || r == '/' || unicode.IsSpace(r)
+		}) {
+			weekday, ok := importWeekdayAliases[strings.ToLower(strings.TrimSuffix(value, "."))]
+			if !ok {
+				row.Errors = append(row.Errors, fmt.Sprintf("Unbekannter Wochentag %q", value))
+				continue
+			}
+			row.Weekdays = append(row.Weekdays, weekday)
+		}
+
+		nameKey := strings.ToLower(row.Firstname + " " + row.Lastname)
+		usernameKey := strings.ToLower(row.Username)
+		for _, user := range existing {
+			if strings.EqualFold(user.Username, row.Username) {
+				row.Duplicate = fmt.Sprintf("Benutzername existiert bereits (%s %s)", user.Firstname, user.Lastname)
+				break
+			}
+			if strings.ToLower(user.Firstname+" "+user.Lastname) == nameKey {
+				row.Duplicate = fmt.Sprintf("Person existiert bereits als %s", user.Username)
+				break
+			}
+		}
+		if row.Duplicate == "" {
+			if other, ok := seenUsernames[usernameKey]; ok {
+				row.Duplicate = fmt.Sprintf("Benutzername doppelt in Zeile %d", other)
+			} else if other, ok := seenNames[nameKey]; ok {
+				row.Duplicate = fmt.Sprintf("Person doppelt in Zeile %d", other)
+			}
+		}
+		seenUsernames[usernameKey] = row.Row
+		seenNames[nameKey] = row.Row
+
+		if len(row.Errors) == 0 && row.Duplicate == "" {
+			result.Valid++
+		} else {
+			result.Invalid++
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	return result, nil
+}
+
+// ImportUsers creates a user with a generated initial password and weekdays
+// for every valid row of the preview. Rows with errors or duplicates are
+// skipped and stay in the result so the admin can fix them.
+func ImportUsers(sheet [][]string, callerPermissions []string) (UserImportResult, error) {
+	result, err := PreviewUserImport(sheet, callerPermissions)
+	if err != nil {
+		return result, err
+	}
+	result.Committed = true
+
+	for i := range result.Rows {
+		row := &result.Rows[i]
+		if len(row.Errors) > 0 || row.Duplicate != "" {
+			continue
+		}
+
+		id, initialPassword, err := CreateUser(NewUser{
+			Firstname:  row.Firstname,
+			Lastname:   row.Lastname,
+			Username:   row.Username,
+			RoleId:     row.RoleId,
+			Incense:    row.Incense,
+			Experience: row.Experience,
+		}, callerPermissions)
+		if err != nil {
+			row.Errors = append(row.Errors, err.Error())
+			continue
+		}
+		if row.Active == 0 {
+			ExecuteDDL("UPDATE user SET active = 0 WHERE id = ?", id)
+		}
+		for _, weekday := range row.Weekdays {
+			AddUserWeekday(strconv.Itoa(id), weekday)
+		}
+		row.CreatedId = id
+		row.InitialPassword = initialPassword
+		result.Created++
+	}
+	return result, nil
+}
+
+// ExportUsers writes GetAllUser together with the weekdays as CSV or XLSX.
+func ExportUsers(format string) ([]byte, error) {
+	rows := [][]string{userExportHeader}
+	for _, user := range GetAllUser() {
+		rows = append(rows, []string{
+			user.Firstname,
+			user.Lastname,
+			user.Username,
+			strconv.Itoa(user.RoleId),
+			strconv.Itoa(user.Active),
+			strconv.Itoa(user.Incense),
+			strconv.Itoa(user.Experience),
+			strings.Join(GetUserWeekdays(strconv.Itoa(user.Id)), ", "),
+		})
+	}
+
+	buf := new(bytes.Buffer)
+	switch format {
+	case "xlsx":
+		f := excelize.NewFile()
+		defer f.Close()
+		sheet := f.GetSheetName(0)
+		for i, row := range rows {
+			cellRef, _ := excelize.CoordinatesToCellName(1, i+1)
+			values := make([]interface{}, len(row))
+			for j, value := range row {
+				values[j] = value
+			}
+			if err := f.SetSheetRow(sheet, cellRef, &values); err != nil {
+				return nil, err
+			}
+		}
+		if err := f.Write(buf); err != nil {
+			return nil, err
+		}
+	case "csv":
+		// UTF-8 BOM so that Excel shows umlauts correctly
+		buf.WriteString("\xef\xbb\xbf")
+		writer := csv.NewWriter(buf)
+		writer.Comma = ';'
+		if err := writer.WriteAll(rows); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("Format %q wird nicht unterstützt, bitte xlsx oder csv verwenden", format)
+	}
+	return buf.Bytes(), nil
+}
+
+func suggestUsername(firstname string, lastname string) string {
+	replacer := strings.NewReplacer("ä", "ae", "ö", "oe", "ü", "ue", "ß", "ss", " ", "", "-", "")
+	return replacer.Replace(strings.ToLower(firstname + "." + lastname))
+}
+
+func parseImportBool(value string) (bool, bool) {
+	switch strings.ToLower(value) {
+	case "1", "ja", "j", "x", "yes", "true", "wahr":
+		return true, true
+	case "0", "nein", "n", "no", "false", "falsch", "-":
+		return false, true
+	default:
+		return false, false
+	}
+}
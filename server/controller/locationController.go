@@ -0,0 +1,77 @@
+package controller
+
+import (
+	"database/sql"
+	"errors"
+	. "minisAPI/models"
+
+	_ "github.com/go-sql-driver/mysql"
+)
+
+var ErrLocationRetired = errors.New("Ort ist nicht mehr aktiv")
+
+// GetLocations lists the locations that can be used for new events. Retired
+// locations are only included on request, e.g. for the admin overview.
+func GetLocations(includeInactive bool) []Location {
+	results := ExecuteSQL(`SELECT id, name, IFNULL(address, ''), IFNULL(notes, ''), active FROM location
+		WHERE active = 1 OR ? ORDER BY active DESC, name`, includeInactive)
+	list := []Location{}
+	for results.Next() {
+		var loc Location
+		results.Scan(&loc.Id, &loc.Name, &loc.Address, &loc.Notes, &loc.Active)
+		list = append(list, loc)
+	}
+	return list
+}
+
+func GetLocation(locationId string) (Location, error) {
+	var loc Location
+	err := ExecuteSQLRow("SELECT id, name, IFNULL(address, ''), IFNULL(notes, ''), active FROM location WHERE id = ?", locationId).
+		Scan(&loc.Id, &loc.Name, &loc.Address, &loc.Notes, &loc.Active)
+	return loc, err
+}
+
+func CreateLocation(loc Location) (int, error) {
+	result, err := db.Exec("INSERT INTO location (name, address, notes, active) VALUES (?, ?, ?, ?)",
+		loc.Name, loc.Address, loc.Notes, loc.Active)
+	if err != nil {
+		return 0, err
+	}
+
+	id, _ := result.LastInsertId()
+	return int(id), nil
+}
+
+// UpdateLocation returns sql.ErrNoRows for an unknown location.
+func UpdateLocation(locationId string, update LocationUpdate) error {
+	var exists bool
+	ExecuteSQLRow("SELECT COUNT(*) FROM location WHERE id = ?", locationId).Scan(&exists)
+	if !exists {
+		return sql.ErrNoRows
+	}
+
+	_, err := db.Exec(`UPDATE location SET name = COALESCE(?, name), address = COALESCE(?, address),
+		notes = COALESCE(?, notes), active = COALESCE(?, active) WHERE id = ?`,
+		update.Name, update.Address, update.Notes, update.Active, locationId)
+	return err
+}
+
+// DeleteLocation removes a location that was never used. Locations that are
+// referenced by events or templates have to be retired instead so that old
+// plans keep their location.
+func DeleteLocation(locationId string) bool {
+	var used bool
+	ExecuteSQLRow(`SELECT EXISTS (SELECT 1 FROM event WHERE location_id = ?)
+		OR EXISTS (SELECT 1 FROM event_template WHERE location_id = ?)`, locationId, locationId).Scan(&used)
+	if used {
+		return false
+	}
+	ExecuteDDL("DELETE FROM location WHERE id = ?", locationId)
+	return true
+}
+
+func IsLocationActive(locationId int) bool {
+	var active bool
+	ExecuteSQLRow("SELECT active FROM location WHERE id = ?", locationId).Scan(&active)
+	return active
+}
@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"fmt"
+	"log"
+	"minisAPI/config"
+	"net"
+	"net/smtp"
+	"regexp"
+	"strings"
+)
+
+// AppURL is the public address of the app, used for links in mails and the
+// PDF. It always ends with a slash.
+var AppURL string
+
+// SetAppURL sets the public address of the app from the configuration.
+func SetAppURL(url string) {
+	AppURL = url
+}
+
+// MailSender delivers plain text mails. The SMTP implementation is used in
+// production; without a configured host mails are only written to the log.
+type MailSender interface {
+	Send(to string, subject string, body string) error
+}
+
+type SMTPMailSender struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func (s SMTPMailSender) Send(to string, subject string, body string) error {
+	var auth smtp.Auth
+	if s.Username != "" {
+		auth = smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	}
+
+	msg := strings.Join([]string{
+		"From: " + s.From,
+		"To: " + to,
+		"Subject: " + subject,
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		body,
+	}, "\r\n")
+
+	return smtp.SendMail(net.JoinHostPort(s.Host, s.Port), auth, s.From, []string{to}, []byte(msg))
+}
+
+// LogMailSender writes mails to the log. Tokens in links are redacted so the
+// log never holds a working reset link.
+type LogMailSender struct{}
+
+var mailTokenPattern = regexp.MustCompile(`token=[^\s&]+`)
+
+func (LogMailSender) Send(to string, subject string, body string) error {
+	log.Printf("mail to %s: %s\n%s", to, subject, mailTokenPattern.ReplaceAllString(body, "token=[redacted]"))
+	return nil
+}
+
+var mailSender MailSender = LogMailSender{}
+
+func SetMailSender(sender MailSender) {
+	mailSender = sender
+}
+
+// InitMail uses SMTP when a mail host is configured, e.g. a local MailHog on
+// port 1025 during development, and the log otherwise.
+func InitMail(cfg config.MailConfig) {
+	if cfg.Host == "" {
+		log.Printf("MAIL_HOST not set, mails are written to the log")
+		return
+	}
+	SetMailSender(SMTPMailSender{
+		Host:     cfg.Host,
+		Port:     cfg.Port,
+		Username: cfg.Username,
+		Password: cfg.Password,
+		From:     cfg.From,
+	})
+}
+
+func sendMail(to string, subject string, body string) error {
+	if err := mailSender.Send(to, subject, body); err != nil {
+		return fmt.Errorf("send mail: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package controller
+
+import (
+	"bufio"
+	"bytes"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"testing"
+)
+
+// fakeSMTPServer accepts a single plain SMTP session on a local port and
+// sends the received envelope and message data to the returned channel.
+func fakeSMTPServer(t *testing.T) (string, string, <-chan string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		reader := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+		var transcript strings.Builder
+
+		reply("220 localhost ESMTP")
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				received <- transcript.String()
+				return
+			}
+			command := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+				reply("250 localhost")
+			case strings.HasPrefix(command, "MAIL FROM"), strings.HasPrefix(command, "RCPT TO"):
+				transcript.WriteString(strings.TrimSpace(line) + "\n")
+				reply("250 OK")
+			case command == "DATA":
+				reply("354 end with <CRLF>.<CRLF>")
+				for {
+					dataLine, err := reader.ReadString('\n')
+					if err != nil || dataLine == ".\r\n" {
+						break
+					}
+					transcript.WriteString(dataLine)
+				}
+				reply("250 OK")
+			case command == "QUIT":
+				reply("221 bye")
+				received <- transcript.String()
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	return host, port, received
+}
+
+func TestSMTPMailSenderSend(t *testing.T) {
+	host, port, received := fakeSMTPServer(t)
+	sender := SMTPMailSender{Host: host, Port: port, From: "minis@example.org"}
+
+	if err := sender.Send("anna@example.org", "Passwort zurücksetzen", "Hallo Anna"); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	transcript := <-received
+	for _, want := range []string{
+		"MAIL FROM:<minis@example.org>",
+		"RCPT TO:<anna@example.org>",
+		"To: anna@example.org\r\n",
+		"Subject: Passwort zurücksetzen\r\n",
+		"Hallo Anna",
+	} {
+		if !strings.Contains(transcript, want) {
+			t.Errorf("transcript misses %q:\n%s", want, transcript)
+		}
+	}
+}
+
+func TestLogMailSenderRedactsTokens(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	LogMailSender{}.Send("anna@example.org", "Passwort zurücksetzen", AppURL+"#/reset?token=abc123&x=1")
+
+	if strings.Contains(buf.String(), "abc123") {
+		t.Errorf("log contains the token: %s", buf.String())
+	}
+	if !strings.Contains(buf.String(), "token=[redacted]&x=1") {
+		t.Errorf("log misses the redacted link: %s", buf.String())
+	}
+}
@@ -0,0 +1,248 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"minisAPI/config"
+	. "minisAPI/models"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/oauth2"
+)
+
+// OIDCStateCookie carries state, nonce and PKCE verifier of a running login
+// from /oidc/login to /oidc/callback. It is signed like the access tokens.
+const (
+	OIDCStateCookie   = "oidc_state"
+	oidcStateValidity = 10 * time.Minute
+)
+
+var (
+	ErrOIDCDisabled  = errors.New("Die Anmeldung über das Bistumskonto ist nicht eingerichtet")
+	ErrOIDCState     = errors.New("Die Anmeldung ist abgelaufen, bitte versuche es erneut")
+	ErrOIDCNoAccount = errors.New("Für dieses Konto gibt es keinen freigegebenen Zugang")
+)
+
+var (
+	oidcConfig   config.OIDCConfig
+	oidcProvider *oidc.Provider
+	oidcMutex    sync.Mutex
+)
+
+type oidcStateClaims struct {
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	jwt.RegisteredClaims
+}
+
+// OIDCIdentity is the verified identity returned by the provider. Issuer and
+// subject identify the person; the other claims are only used to link the
+// identity to an account on the first login.
+type OIDCIdentity struct {
+	Issuer        string
+	Subject       string
+	Username      string
+	Email         string
+	EmailVerified bool
+}
+
+func InitOIDC(cfg config.OIDCConfig) {
+	oidcConfig = cfg
+	if !cfg.Enabled() {
+		log.Printf("OIDC_ISSUER not set, login through OpenID Connect is disabled")
+	}
+}
+
+func OIDCEnabled() bool {
+	return oidcConfig.Enabled()
+}
+
+// oauthConfig discovers the provider on first use, so the server also starts
+// while the identity provider is unreachable.
+func oauthConfig(ctx context.Context) (*oauth2.Config, *oidc.Provider, error) {
+	if !OIDCEnabled() {
+		return nil, nil, ErrOIDCDisabled
+	}
+
+	oidcMutex.Lock()
+	defer oidcMutex.Unlock()
+	if oidcProvider == nil {
+		provider, err := oidc.NewProvider(ctx, oidcConfig.IssuerURL)
+		if err != nil {
+			return nil, nil, fmt.Errorf("discover oidc provider: %w", err)
+		}
+		oidcProvider = provider
+	}
+
+	return &oauth2.Config{
+		ClientID:     oidcConfig.ClientID,
+		ClientSecret: oidcConfig.ClientSecret,
+		RedirectURL:  oidcConfig.RedirectURL,
+		Endpoint:     oidcProvider.Endpoint(),
+		Scopes:       []string{oidc.ScopeOpenID, "profile", "email"},
+	}, oidcProvider, nil
+}
+
+// StartOIDCLogin returns the URL of the provider login page and the signed
+// value for the state cookie.
+func StartOIDCLogin(ctx context.Context) (string, string, error) {
+	oauth, _, err := oauthConfig(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	state, err := generateToken()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := generateToken()
+	if err != nil {
+		return "", "", err
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	cookie, err := jwt.NewWithClaims(jwt.SigningMethodHS256, oidcStateClaims{
+		State:    state,
+		Nonce:    nonce,
+		Verifier: verifier,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(oidcStateValidity)),
+		},
+	}).SignedString(jwtSecret)
+	if err != nil {
+		return "", "", err
+	}
+
+	authURL := oauth.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier))
+	return authURL, cookie, nil
+}
+
+// FinishOIDCLogin exchanges the authorization code and verifies the ID token
+// against the state cookie of the browser.
+func FinishOIDCLogin(ctx context.Context, code string, state string, cookie string) (OIDCIdentity, error) {
+	oauth, provider, err := oauthConfig(ctx)
+	if err != nil {
+		return OIDCIdentity{}, err
+	}
+
+	stateClaims := &oidcStateClaims{}
+	_, err = jwt.ParseWithClaims(cookie, stateClaims, func(token *jwt.Token) (interface{}, error) {
+		return jwtSecret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil || state == "" || stateClaims.State != state {
+		return OIDCIdentity{}, ErrOIDCState
+	}
+
+	token, err := oauth.Exchange(ctx, code, oauth2.VerifierOption(stateClaims.Verifier))
+	if err != nil {
+		return OIDCIdentity{}, fmt.Errorf("exchange code: %w", err)
+	}
+	rawIdToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return OIDCIdentity{}, errors.New("no id_token in token response")
+	}
+	idToken, err := provider.Verifier(&oidc.Config{ClientID: oidcConfig.ClientID}).Verify(ctx, rawIdToken)
+	if err != nil {
+		return OIDCIdentity{}, fmt.Errorf("verify id_token: %w", err)
+	}
+	if idToken.Nonce != stateClaims.Nonce {
+		return OIDCIdentity{}, ErrOIDCState
+	}
+
+	var claims struct {
+		PreferredUsername string `json:"preferred_username"`
+		Email             string `json:"email"`
+		EmailVerified     bool   `json:"email_verified"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return OIDCIdentity{}, fmt.Errorf("read id_token claims: %w", err)
+	}
+	return OIDCIdentity{
+		Issuer:        idToken.Issuer,
+		Subject:       idToken.Subject,
+		Username:      claims.PreferredUsername,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+	}, nil
+}
+
+// OIDCSession maps the identity to a user through the stored binding of
+// issuer and subject and starts a session like DoLogin. Without a binding the
+// identity is linked once, see oidcLinkCandidate.
+func OIDCSession(identity OIDCIdentity, c *gin.Context) (AccessToken, error) {
+	var userId int
+	ExecuteSQLRow("SELECT user_id FROM oidc_identity WHERE issuer = ? AND subject = ?", identity.Issuer, identity.Subject).Scan(&userId)
+	if userId == 0 {
+		userId = oidcLinkCandidate(identity)
+		if userId == 0 {
+			log.Printf("oidc login of %q (%s) without matching user", identity.Username, identity.Subject)
+			return AccessToken{}, ErrOIDCNoAccount
+		}
+		if _, err := db.Exec("INSERT INTO oidc_identity (issuer, subject, user_id) VALUES (?, ?, ?)",
+			identity.Issuer, identity.Subject, userId); err != nil {
+			return AccessToken{}, fmt.Errorf("bind oidc identity: %w", err)
+		}
+		log.Printf("linked oidc identity %s of %s to user %d", identity.Subject, identity.Issuer, userId)
+	}
+
+	if isRegistrationPending(userId) {
+		return AccessToken{}, ErrOIDCNoAccount
+	}
+	if isDeactivated(userId) {
+		return AccessToken{}, ErrAccountDeactivated
+	}
+	recordLogin(userId, c, true)
+	return startSession(GetUser(strconv.Itoa(userId)), c)
+}
+
+// oidcLinkCandidate finds the account for the first login of an identity: the
+// account with the same username, or else the only account with the verified
+// email address. Accounts that are already bound to another subject of the
+// same issuer are never taken over.
+func oidcLinkCandidate(identity OIDCIdentity) int {
+	const unbound = `registration_status = 'approved' AND erased_at IS NULL
+		AND NOT EXISTS (SELECT 1 FROM oidc_identity i WHERE i.user_id = u.id AND i.issuer = ?)`
+
+	var userId int
+	if identity.Username != "" {
+		ExecuteSQLRow("SELECT id FROM user u WHERE UPPER(username) = UPPER(?) AND "+unbound,
+			identity.Username, identity.Issuer).Scan(&userId)
+	}
+	if userId == 0 && identity.Email != "" && identity.EmailVerified {
+		// siblings may share an address, then it does not identify anyone
+		var matches int
+		ExecuteSQLRow("SELECT COUNT(*), IFNULL(MIN(id), 0) FROM user u WHERE UPPER(email) = UPPER(?) AND "+unbound,
+			identity.Email, identity.Issuer).Scan(&matches, &userId)
+		if matches != 1 {
+			userId = 0
+		}
+	}
+	return userId
+}
+
+// OIDCRedirect builds the address of the web app that receives the tokens in
+// the URL fragment, which is never sent to a server. An empty string means
+// no app address is configured and the tokens are returned as JSON.
+func OIDCRedirect(token AccessToken, loginError error) string {
+	if oidcConfig.UIURL == "" {
+		return ""
+	}
+	fragment := url.Values{}
+	if loginError != nil {
+		fragment.Set("loginError", loginError.Error())
+	} else {
+		fragment.Set("accessToken", token.AccessToken)
+		fragment.Set("refreshToken", token.RefreshToken)
+		fragment.Set("expiresIn", strconv.Itoa(token.ExpiresIn))
+	}
+	return oidcConfig.UIURL + "#" + fragment.Encode()
+}
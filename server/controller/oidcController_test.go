@@ -0,0 +1,184 @@
+package controller
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"minisAPI/config"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// mockOIDCProvider is a minimal OpenID Connect provider with discovery, key
+// set and token endpoint. The ID token carries the nonce and checks the PKCE
+// challenge of the last authorization request.
+type mockOIDCProvider struct {
+	server    *httptest.Server
+	key       *rsa.PrivateKey
+	nonce     string
+	challenge string
+	claims    jwt.MapClaims
+}
+
+func newMockOIDCProvider(t *testing.T) *mockOIDCProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	provider := &mockOIDCProvider{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		issuer := provider.server.URL
+		json.NewEncoder(w).Encode(map[string]any{
+			"issuer":                                issuer,
+			"authorization_endpoint":                issuer + "/auth",
+			"token_endpoint":                        issuer + "/token",
+			"jwks_uri":                              issuer + "/keys",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"alg": "RS256",
+			"use": "sig",
+			"kid": "test",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		verifierHash := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if r.PostForm.Get("code") != "valid-code" || base64.RawURLEncoding.EncodeToString(verifierHash[:]) != provider.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		claims := jwt.MapClaims{
+			"iss":   provider.server.URL,
+			"aud":   "minis",
+			"sub":   "subject-1",
+			"nonce": provider.nonce,
+			"iat":   time.Now().Unix(),
+			"exp":   time.Now().Add(time.Minute).Unix(),
+		}
+		for name, value := range provider.claims {
+			claims[name] = value
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = "test"
+		idToken, err := token.SignedString(key)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"expires_in":   60,
+			"id_token":     idToken,
+		})
+	})
+	provider.server = httptest.NewServer(mux)
+	t.Cleanup(provider.server.Close)
+
+	SetJWTSecret("test-secret-with-at-least-32-characters")
+	InitOIDC(config.OIDCConfig{
+		IssuerURL:    provider.server.URL,
+		ClientID:     "minis",
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost:8080/oidc/callback",
+	})
+	oidcProvider = nil
+	t.Cleanup(func() {
+		InitOIDC(config.OIDCConfig{})
+		oidcProvider = nil
+	})
+	return provider
+}
+
+// startLogin runs StartOIDCLogin and remembers nonce and PKCE challenge of the
+// authorization request like the provider login page would.
+func (p *mockOIDCProvider) startLogin(t *testing.T) (string, string) {
+	t.Helper()
+	authURL, cookie, err := StartOIDCLogin(context.Background())
+	if err != nil {
+		t.Fatalf("start login: %v", err)
+	}
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("parse auth url: %v", err)
+	}
+	query := parsed.Query()
+	p.nonce = query.Get("nonce")
+	p.challenge = query.Get("code_challenge")
+	return query.Get("state"), cookie
+}
+
+func TestFinishOIDCLogin(t *testing.T) {
+	provider := newMockOIDCProvider(t)
+	provider.claims = jwt.MapClaims{
+		"preferred_username": "anna.muster",
+		"email":              "anna@example.org",
+		"email_verified":     true,
+	}
+	state, cookie := provider.startLogin(t)
+
+	identity, err := FinishOIDCLogin(context.Background(), "valid-code", state, cookie)
+	if err != nil {
+		t.Fatalf("finish login: %v", err)
+	}
+	want := OIDCIdentity{
+		Issuer:        provider.server.URL,
+		Subject:       "subject-1",
+		Username:      "anna.muster",
+		Email:         "anna@example.org",
+		EmailVerified: true,
+	}
+	if identity != want {
+		t.Errorf("identity = %+v, want %+v", identity, want)
+	}
+}
+
+func TestFinishOIDCLoginRejectsForeignState(t *testing.T) {
+	provider := newMockOIDCProvider(t)
+	_, cookie := provider.startLogin(t)
+
+	if _, err := FinishOIDCLogin(context.Background(), "valid-code", "other-state", cookie); err != ErrOIDCState {
+		t.Errorf("err = %v, want ErrOIDCState", err)
+	}
+}
+
+func TestFinishOIDCLoginRejectsReplayedNonce(t *testing.T) {
+	provider := newMockOIDCProvider(t)
+	state, cookie := provider.startLogin(t)
+	provider.nonce = "nonce-of-another-login"
+
+	if _, err := FinishOIDCLogin(context.Background(), "valid-code", state, cookie); err != ErrOIDCState {
+		t.Errorf("err = %v, want ErrOIDCState", err)
+	}
+}
+
+func TestFinishOIDCLoginRejectsUnverifiedToken(t *testing.T) {
+	provider := newMockOIDCProvider(t)
+	provider.claims = jwt.MapClaims{"aud": "another-client"}
+	state, cookie := provider.startLogin(t)
+
+	if _, err := FinishOIDCLogin(context.Background(), "valid-code", state, cookie); err == nil {
+		t.Error("token for another client was accepted")
+	}
+}
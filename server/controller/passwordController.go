@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"crypto/subtle"
+	"errors"
+	"log"
+	"strings"
+	"unicode"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const minPasswordLength = 8
+
+// dummyPasswordHash is compared against when there is no hash to check, so a
+// login for an unknown username takes as long as one with a wrong password.
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("no-such-user"), bcrypt.DefaultCost)
+
+// HashPassword returns the bcrypt hash that is stored in user.password.
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// ValidatePassword enforces the minimum password policy for new passwords.
+func ValidatePassword(password string) error {
+	if len([]rune(password)) < minPasswordLength {
+		return errors.New("Das Passwort muss mindestens 8 Zeichen lang sein")
+	}
+
+	var hasLetter, hasDigit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+	if !hasLetter || !hasDigit {
+		return errors.New("Das Passwort muss Buchstaben und Ziffern enthalten")
+	}
+	return nil
+}
+
+// checkPassword compares a login attempt with the stored password of a user.
+// Rows that still hold a plaintext password from before hashing was introduced
+// are accepted once and re-hashed, so existing accounts migrate on their own.
+func checkPassword(userId int, stored string, password string) bool {
+	if isPasswordHash(stored) {
+		return bcrypt.CompareHashAndPassword([]byte(stored), []byte(password)) == nil
+	}
+
+	// unknown users, erased accounts and plaintext rows pay for a bcrypt run as well
+	bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+	if stored == "" || subtle.ConstantTimeCompare([]byte(stored), []byte(password)) != 1 {
+		return false
+	}
+
+	hash, err := HashPassword(password)
+	if err != nil {
+		log.Printf("rehash password for user %d failed: %v", userId, err)
+		return true
+	}
+	ExecuteDDL("UPDATE user SET password=? WHERE id=?", hash, userId)
+	log.Printf("migrated plaintext password of user %d", userId)
+	return true
+}
+
+func isPasswordHash(stored string) bool {
+	return strings.HasPrefix(stored, "$2a$") || strings.HasPrefix(stored, "$2b$") || strings.HasPrefix(stored, "$2y$")
+}
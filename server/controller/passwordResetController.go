@@ -0,0 +1,125 @@
+package controller
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+)
+
+const passwordResetValidity = time.Hour
+
+var ErrInvalidResetToken = errors.New("Der Link ist ungültig oder abgelaufen")
+
+// RequestPasswordReset creates a single-use reset token for the user with the
+// given username or email and mails the reset link. Siblings often share the
+// email address of a parent, so every account with that address gets its own
+// mail. Every request counts for the reset throttle. Unknown users, users
+// without an email address and failures are only logged, so the caller always
+// gives the same answer and does not reveal which accounts exist.
+func RequestPasswordReset(login string, ip string) {
+	recordPasswordResetRequest(login, ip)
+
+	results := ExecuteSQL(`SELECT id, email, firstname, username FROM user
+		WHERE active = 1 AND email IS NOT NULL AND email <> ''
+		AND (UPPER(username) = UPPER(?) OR UPPER(email) = UPPER(?))`, login, login)
+	if results == nil {
+		log.Printf("password reset lookup for %q failed", login)
+		return
+	}
+	type resetAccount struct {
+		userId                     int
+		email, firstname, username string
+	}
+	accounts := []resetAccount{}
+	for results.Next() {
+		var account resetAccount
+		results.Scan(&account.userId, &account.email, &account.firstname, &account.username)
+		accounts = append(accounts, account)
+	}
+	if len(accounts) == 0 {
+		log.Printf("password reset requested for unknown login %q", login)
+		return
+	}
+
+	for _, account := range accounts {
+		if err := sendPasswordReset(account.userId, account.email, account.firstname, account.username); err != nil {
+			log.Printf("password reset for user %d failed: %v", account.userId, err)
+		}
+	}
+}
+
+func sendPasswordReset(userId int, email string, firstname string, username string) error {
+	token, err := generateToken()
+	if err != nil {
+		return err
+	}
+	if _, err := db.Exec("INSERT INTO password_reset (token_hash, user_id, expires_at) VALUES (?, ?, ?)",
+		hashToken(token), userId, time.Now().Add(passwordResetValidity)); err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf(`Hallo %s,
+
+für dein Konto %q wurde ein neues Passwort angefordert. Über diesen Link kannst du es innerhalb einer Stunde setzen:
+
+%s#/reset?token=%s
+
+Falls du das nicht warst, kannst du diese Mail ignorieren.`, firstname, username, AppURL, token)
+
+	return sendMail(email, "Passwort zurücksetzen", body)
+}
+
+// ResetPasswordWithToken sets a new password if the token exists, has not
+// expired and was not used before. All other open tokens of the user are
+// invalidated as well.
+func ResetPasswordWithToken(token string, password string) error {
+	if err := ValidatePassword(password); err != nil {
+		return err
+	}
+
+	var userId int
+	err := ExecuteSQLRow("SELECT user_id FROM password_reset WHERE token_hash = ? AND used_at IS NULL AND expires_at > ?",
+		hashToken(token), time.Now()).Scan(&userId)
+	if err != nil {
+		return ErrInvalidResetToken
+	}
+
+	result := ExecuteDDL("UPDATE password_reset SET used_at = ? WHERE token_hash = ? AND used_at IS NULL", time.Now(), hashToken(token))
+	if result == nil {
+		return ErrInvalidResetToken
+	}
+	if count, _ := result.RowsAffected(); count == 0 {
+		// the token was used concurrently
+		return ErrInvalidResetToken
+	}
+
+	hash, err := HashPassword(password)
+	if err != nil {
+		return err
+	}
+	ExecuteDDL("UPDATE user SET password=? WHERE id=?", hash, userId)
+	ExecuteDDL("UPDATE password_reset SET used_at = ? WHERE user_id = ? AND used_at IS NULL", time.Now(), userId)
+	RevokeUserSessions(strconv.Itoa(userId))
+	log.Printf("password of user %d reset by token", userId)
+	return nil
+}
+
+func generateToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// only the hash of reset and refresh tokens is stored, so a leaked database
+// does not contain usable links or sessions
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -21,16 +21,35 @@ const (
 	ColorTextR, ColorTextG, ColorTextB          = 50, 50, 50    // Dark Grey text
 )
 
+// PdfResources are the font and logo files used in the plan.
+type PdfResources struct {
+	FontPath     string
+	FontBoldPath string
+	LogoPath     string
+}
+
+var pdfResources = PdfResources{
+	FontPath:     "ressources/arial-unicode-ms.ttf",
+	FontBoldPath: "ressources/arial-unicode-ms-bold.ttf",
+	LogoPath:     "ressources/logoRemBG.png",
+}
+
+func SetPdfResources(resources PdfResources) {
+	pdfResources = resources
+}
+
 // -----------------------------------------------------------------------------
 // Data Structures
 // -----------------------------------------------------------------------------
 
 type PdfEvent struct {
-	ID        int
-	Name      string
-	DateBegin string // Format YYYY-MM-DD
-	TimeBegin string // Format HH:MM:SS
-	Location  string
+	ID           int
+	Name         string
+	DateBegin    string // Format YYYY-MM-DD
+	TimeBegin    string // Format HH:MM:SS
+	Location     string
+	Category     string // category key, e.g. "mass"
+	CategoryName string
 }
 
 type AssignedUser struct {
@@ -56,10 +75,9 @@ func CreateEventPlanPDF(db *sql.DB, startDate string, endDate string) ([]byte, e
 	// Initialize PDF
 	pdf := fpdf.New("P", "mm", "A4", "")
 
-	// Note: Ensure these paths are correct for your project structure
-	pdf.AddUTF8Font("myArial", "", "ressources/arial-unicode-ms.ttf")
-	pdf.AddUTF8Font("myArial", "B", "ressources/arial-unicode-ms-bold.ttf")
-	pdf.AddUTF8Font("myArial", "I", "ressources/arial-unicode-ms.ttf") // Using regular as italic placeholder if bold not avail
+	pdf.AddUTF8Font("myArial", "", pdfResources.FontPath)
+	pdf.AddUTF8Font("myArial", "B", pdfResources.FontBoldPath)
+	pdf.AddUTF8Font("myArial", "I", pdfResources.FontPath) // Using regular as italic placeholder if bold not avail
 
 	// Set Header and Footer
 	pdf.SetHeaderFunc(func() { drawHeader(pdf, startDate, endDate) })
@@ -104,9 +122,8 @@ func drawHeader(pdf *fpdf.Fpdf, start, end string) {
 	pdf.Rect(0, 0, 210, 30, "F")
 
 	// 2. Logo einfügen
-	// Pfad: ressources/logo.png
 	// X: 10mm, Y: 5mm, Breite: 0 (automatisch proportional), Höhe: 20mm
-	pdf.ImageOptions("ressources/logoRemBG.png", 10, 5, 0, 20, false, fpdf.ImageOptions{ReadDpi: true}, 0, "")
+	pdf.ImageOptions(pdfResources.LogoPath, 10, 5, 0, 20, false, fpdf.ImageOptions{ReadDpi: true}, 0, "")
 
 	// 3. Titel (Zentriert)
 	pdf.SetY(8)
@@ -139,7 +156,7 @@ func drawFooter(pdf *fpdf.Fpdf) {
 	// Linke Seite: URL
 	// Wir setzen X auf den linken Rand (15mm)
 	pdf.SetX(15)
-	pdf.CellFormat(0, 10, "https://ministranten.dynv6.net:33333/", "", 0, "L", false, 0, "")
+	pdf.CellFormat(0, 10, AppURL, "", 0, "L", false, 0, "")
 
 	// Rechte Seite: Seitenzahl
 	// Wir setzen X wieder zurück, um "über" die Zeile zu schreiben, aber rechtsbündig
@@ -192,7 +209,12 @@ func drawEventBlock(pdf *fpdf.Fpdf, ev FullEvent) {
 	// Event Name
 	pdf.SetTextColor(ColorTextR, ColorTextG, ColorTextB)
 	pdf.SetFont("myArial", "B", 12)
-	pdf.CellFormat(0, 6, ev.Event.Name, "", 1, "L", false, 0, "")
+	title := ev.Event.Name
+	// Regular masses are the default, every other category is named
+	if ev.Event.Category != defaultCategory && ev.Event.CategoryName != "" {
+		title = fmt.Sprintf("%s (%s)", title, ev.Event.CategoryName)
+	}
+	pdf.CellFormat(0, 6, title, "", 1, "L", false, 0, "")
 
 	// Location
 	pdf.SetXY(50, pdf.GetY())                                  // Indent
@@ -287,7 +309,7 @@ func formatDateShort(dbDate string) string {
 // -----------------------------------------------------------------------------
 
 func loadEventsWithAssignedUsers(db *sql.DB, startDate string, endDate string) ([]FullEvent, error) {
-	queryEvents := `SELECT e.id, e.name, e.date_begin, e.time_begin, l.name FROM event e LEFT JOIN location l ON e.location_id = l.id WHERE e.date_begin BETWEEN ? AND ? ORDER BY e.date_begin, e.time_begin`
+	queryEvents := `SELECT e.id, e.name, e.date_begin, e.time_begin, l.name, e.category, IFNULL(c.name, '') FROM event e LEFT JOIN location l ON e.location_id = l.id LEFT JOIN event_category c ON c.category = e.category WHERE e.date_begin BETWEEN ? AND ? AND e.status = 'published' ORDER BY e.date_begin, e.time_begin`
 	rows, err := db.Query(queryEvents, startDate, endDate)
 	if err != nil {
 		return nil, err
@@ -297,7 +319,7 @@ func loadEventsWithAssignedUsers(db *sql.DB, startDate string, endDate string) (
 	var result []FullEvent
 	for rows.Next() {
 		var ev PdfEvent
-		if err := rows.Scan(&ev.ID, &ev.Name, &ev.DateBegin, &ev.TimeBegin, &ev.Location); err != nil {
+		if err := rows.Scan(&ev.ID, &ev.Name, &ev.DateBegin, &ev.TimeBegin, &ev.Location, &ev.Category, &ev.CategoryName); err != nil {
 			return nil, err
 		}
 		users, _ := loadAssignedUsers(db, ev.ID) // Error handling omitted for brevity
@@ -307,7 +329,7 @@ func loadEventsWithAssignedUsers(db *sql.DB, startDate string, endDate string) (
 }
 
 func loadAssignedUsers(db *sql.DB, eventID int) ([]AssignedUser, error) {
-	queryUsers := `SELECT u.firstname, u.lastname FROM plan p INNER JOIN user u ON p.user_id = u.id WHERE p.event_id = ? ORDER BY u.lastname, u.firstname`
+	queryUsers := `SELECT u.firstname, u.lastname FROM plan p INNER JOIN user u ON p.user_id = u.id WHERE p.event_id = ? AND p.status = 'published' ORDER BY u.lastname, u.firstname`
 	rows, err := db.Query(queryUsers, eventID)
 	if err != nil {
 		return nil, err
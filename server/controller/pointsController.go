@@ -0,0 +1,114 @@
+package controller
+
+import (
+	"database/sql"
+	"errors"
+	. "minisAPI/models"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+)
+
+var (
+	ErrPointsZero          = errors.New("Punkte dürfen nicht 0 sein")
+	ErrPointsReasonMissing = errors.New("Für eine Korrektur ist eine Begründung nötig")
+)
+
+// syncAttendancePoints keeps the ledger in line with the attendance of one
+// assignment: an attended service books the points of the event category,
+// any other attendance removes the booking again. Archived entries are never
+// touched.
+func syncAttendancePoints(eventId string, userId int, recordedBy int) {
+	ExecuteDDL("DELETE FROM points_entry WHERE source = 'attendance' AND event_id = ? AND user_id = ? AND archived_at IS NULL", eventId, userId)
+	ExecuteDDL(`INSERT INTO points_entry (user_id, year, points, source, event_id, created_by)
+		SELECT p.user_id, YEAR(e.date_begin), IFNULL(c.points, 1), 'attendance', e.id, ?
+		FROM plan p
+		INNER JOIN event e ON e.id = p.event_id
+		LEFT JOIN event_category c ON c.category = e.category
+		WHERE p.event_id = ? AND p.user_id = ? AND p.attendance = 'present' AND IFNULL(c.points, 1) <> 0
+		AND NOT EXISTS (
+			SELECT 1 FROM points_entry pe
+			WHERE pe.source = 'attendance' AND pe.event_id = p.event_id AND pe.user_id = p.user_id
+		)`, recordedBy, eventId, userId)
+}
+
+// AdjustPoints books a manual correction for the current year. It returns
+// sql.ErrNoRows if the user does not exist or has been erased.
+func AdjustPoints(userId string, adjustment PointsAdjustment, createdBy int) error {
+	if adjustment.Points == 0 {
+		return ErrPointsZero
+	}
+	if adjustment.Reason == "" {
+		return ErrPointsReasonMissing
+	}
+	result, err := db.Exec(`INSERT INTO points_entry (user_id, year, points, source, reason, created_by)
+		SELECT id, ?, ?, 'manual', ?, ? FROM user WHERE id = ? AND erased_at IS NULL`,
+		time.Now().Year(), adjustment.Points, adjustment.Reason, createdBy, userId)
+	if err != nil {
+		return err
+	}
+	if count, _ := result.RowsAffected(); count == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetLeaderboard ranks all active servers by their points. Without a year
+// the open (not archived) entries count, otherwise the entries of that year.
+// Servers with equal points share a rank.
+func GetLeaderboard(year int) []PointsBalance {
+	results := ExecuteSQL(`SELECT u.id, u.firstname, u.lastname, IFNULL(SUM(pe.points), 0) AS total
+		FROM user u
+		LEFT JOIN points_entry pe ON pe.user_id = u.id
+			AND ((? = 0 AND pe.archived_at IS NULL) OR pe.year = ?)
+		WHERE u.active = 1 AND u.`+servingRoles+`
+		GROUP BY u.id, u.firstname, u.lastname
+		ORDER BY total DESC, u.lastname, u.firstname`, year, year)
+	list := []PointsBalance{}
+	for results.Next() {
+		var balance PointsBalance
+		results.Scan(&balance.UserId, &balance.Firstname, &balance.Lastname, &balance.Points)
+		balance.Rank = len(list) + 1
+		if len(list) > 0 && list[len(list)-1].Points == balance.Points {
+			balance.Rank = list[len(list)-1].Rank
+		}
+		list = append(list, balance)
+	}
+	return list
+}
+
+// GetUserPoints returns the open balance of a user with all ledger entries,
+// archived ones included.
+func GetUserPoints(userId string) PointsBalance {
+	var balance PointsBalance
+	ExecuteSQLRow(`SELECT u.id, u.firstname, u.lastname,
+		IFNULL((SELECT SUM(points) FROM points_entry WHERE user_id = u.id AND archived_at IS NULL), 0)
+		FROM user u WHERE u.id = ?`, userId).Scan(&balance.UserId, &balance.Firstname, &balance.Lastname, &balance.Points)
+
+	results := ExecuteSQL(`SELECT pe.id, pe.year, pe.points, pe.source, pe.event_id, IFNULL(e.name, ''), IFNULL(pe.reason, ''),
+		pe.created_at, IFNULL(pe.archived_at, '')
+		FROM points_entry pe
+		LEFT JOIN event e ON e.id = pe.event_id
+		WHERE pe.user_id = ?
+		ORDER BY pe.created_at DESC, pe.id DESC`, userId)
+	balance.Entries = []PointsEntry{}
+	for results.Next() {
+		var entry PointsEntry
+		results.Scan(&entry.Id, &entry.Year, &entry.Points, &entry.Source, &entry.EventId, &entry.EventName, &entry.Reason,
+			&entry.CreatedAt, &entry.ArchivedAt)
+		balance.Entries = append(balance.Entries, entry)
+	}
+	return balance
+}
+
+// ArchivePoints closes all open entries up to the given year, e.g. after the
+// thank-you outing. The entries stay in the ledger but no longer count for
+// the open balance.
+func ArchivePoints(year int) int {
+	result := ExecuteDDL("UPDATE points_entry SET archived_at = NOW() WHERE year <= ? AND archived_at IS NULL", year)
+	if result == nil {
+		return 0
+	}
+	count, _ := result.RowsAffected()
+	return int(count)
+}
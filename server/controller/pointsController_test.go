@@ -0,0 +1,21 @@
+package controller
+
+import (
+	"database/sql"
+	. "minisAPI/models"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestAdjustPointsRefusesUnknownUser(t *testing.T) {
+	mock := withMockDB(t)
+	mock.ExpectExec(`INSERT INTO points_entry \(user_id, year, points, source, reason, created_by\)\s+SELECT id, \?, \?, 'manual', \?, \? FROM user WHERE id = \? AND erased_at IS NULL`).
+		WithArgs(time.Now().Year(), 5.0, "Sonderdienst", 2, "99").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if err := AdjustPoints("99", PointsAdjustment{Points: 5, Reason: "Sonderdienst"}, 2); err != sql.ErrNoRows {
+		t.Errorf("err = %v, want sql.ErrNoRows", err)
+	}
+}
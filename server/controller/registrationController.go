@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"crypto/subtle"
+	"errors"
+	"log"
+	. "minisAPI/models"
+	"strconv"
+	"strings"
+
+	_ "github.com/go-sql-driver/mysql"
+)
+
+var (
+	ErrRegistrationClosed  = errors.New("Die Registrierung ist derzeit nicht möglich")
+	ErrInvalidInviteCode   = errors.New("Der Einladungscode ist ungültig")
+	ErrRegistrationMissing = errors.New("Registrierung nicht gefunden")
+)
+
+// Register creates a pending server account. The account stays inactive and
+// cannot log in until an admin approves it. Every attempt counts for the
+// registration throttle of the IP.
+func Register(registration Registration, ip string) (int, error) {
+	recordRegistrationAttempt(registration.Username, ip)
+
+	inviteCode := GetSetting(SettingRegistrationInviteCode)
+	if inviteCode == "" {
+		return 0, ErrRegistrationClosed
+	}
+	if subtle.ConstantTimeCompare([]byte(inviteCode), []byte(strings.TrimSpace(registration.InviteCode))) != 1 {
+		return 0, ErrInvalidInviteCode
+	}
+
+	registration.Firstname = strings.TrimSpace(registration.Firstname)
+	registration.Lastname = strings.TrimSpace(registration.Lastname)
+	registration.Username = strings.TrimSpace(registration.Username)
+	registration.Email = strings.TrimSpace(registration.Email)
+	registration.Phone = strings.TrimSpace(registration.Phone)
+	if registration.Firstname == "" || registration.Lastname == "" || registration.Username == "" {
+		return 0, errors.New("Vorname, Nachname und Benutzername sind erforderlich")
+	}
+	if registration.Email == "" && registration.Phone == "" {
+		return 0, errors.New("Bitte gib eine E-Mail-Adresse oder Telefonnummer an")
+	}
+
+	weekdays := []string{}
+	for _, value := range registration.Weekdays {
+		weekday, ok := importWeekdayAliases[strings.ToLower(strings.TrimSpace(value))]
+		if !ok {
+			return 0, errors.New("Unbekannter Wochentag: " + value)
+		}
+		weekdays = append(weekdays, weekday)
+	}
+
+	if err := ValidatePassword(registration.Password); err != nil {
+		return 0, err
+	}
+	hash, err := HashPassword(registration.Password)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := db.Exec(`INSERT INTO user (firstname, lastname, username, password, role_id, active, incense, experience, email, phone, registration_status, registered_at)
+		VALUES (?, ?, ?, ?, 1, 0, 0, 0, NULLIF(?, ''), NULLIF(?, ''), 'pending', NOW())`,
+		registration.Firstname, registration.Lastname, registration.Username, hash, registration.Email, registration.Phone)
+	if isDuplicateEntry(err) {
+		return 0, ErrUsernameTaken
+	}
+	if err != nil {
+		return 0, err
+	}
+	id, _ := result.LastInsertId()
+	for _, weekday := range weekdays {
+		AddUserWeekday(strconv.Itoa(int(id)), weekday)
+	}
+	log.Printf("New registration of %s %s (user %d)", registration.Firstname, registration.Lastname, id)
+	return int(id), nil
+}
+
+func GetPendingRegistrations() []PendingRegistration {
+	results := ExecuteSQL(`SELECT id, firstname, lastname, username, IFNULL(email, ''), IFNULL(phone, ''), IFNULL(registered_at, '')
+		FROM user WHERE registration_status = 'pending' ORDER BY registered_at, id`)
+	list := []PendingRegistration{}
+	for results.Next() {
+		var registration PendingRegistration
+		results.Scan(&registration.Id, &registration.Firstname, &registration.Lastname, &registration.Username,
+			&registration.Email, &registration.Phone, &registration.RegisteredAt)
+		list = append(list, registration)
+	}
+	for i := range list {
+		list[i].Weekdays = GetUserWeekdays(strconv.Itoa(list[i].Id))
+	}
+	return list
+}
+
+// DecideRegistration activates an approved account. A rejected registration
+// is deleted, since nothing else references a user that never logged in.
+func DecideRegistration(userId string, approve bool) error {
+	var email string
+	var pending bool
+	ExecuteSQLRow("SELECT COUNT(*), IFNULL(MAX(email), '') FROM user WHERE id = ? AND registration_status = 'pending'", userId).Scan(&pending, &email)
+	if !pending {
+		return ErrRegistrationMissing
+	}
+
+	if !approve {
+		if err := DeleteUser(userId); err != nil {
+			return err
+		}
+		log.Printf("Rejected registration of user %s", userId)
+		return nil
+	}
+
+	ExecuteDDL("UPDATE user SET registration_status = 'approved', active = 1 WHERE id = ?", userId)
+	log.Printf("Approved registration of user %s", userId)
+	if email != "" {
+		body := "Hallo,\n\ndein Konto wurde freigegeben. Du kannst dich jetzt unter " + AppURL + " anmelden.\n"
+		if err := sendMail(email, "Dein Konto wurde freigegeben", body); err != nil {
+			log.Printf("approval mail for user %s failed: %v", userId, err)
+		}
+	}
+	return nil
+}
+
+func isRegistrationPending(userId int) bool {
+	var pending bool
+	ExecuteSQLRow("SELECT COUNT(*) FROM user WHERE id = ? AND registration_status = 'pending'", userId).Scan(&pending)
+	return pending
+}
@@ -0,0 +1,34 @@
+package controller
+
+import (
+	. "minisAPI/models"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestRegisterMapsDuplicateUsername(t *testing.T) {
+	mock := withMockDB(t)
+	mock.ExpectExec(`DELETE FROM login_failure`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO login_failure \(kind, username, ip\) VALUES \(\?, \?, \?\)`).
+		WithArgs("registration", "anna.muster", "203.0.113.7").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(`SELECT value FROM setting WHERE setting_key = \?`).
+		WithArgs(SettingRegistrationInviteCode).
+		WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow("willkommen"))
+	mock.ExpectExec(`INSERT INTO user`).
+		WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'anna.muster' for key 'user_username_unique'"})
+
+	registration := Registration{
+		InviteCode: "willkommen",
+		Firstname:  "Anna",
+		Lastname:   "Muster",
+		Username:   "anna.muster",
+		Password:   "geheim123",
+		Email:      "anna@example.org",
+	}
+	if _, err := Register(registration, "203.0.113.7"); err != ErrUsernameTaken {
+		t.Errorf("err = %v, want ErrUsernameTaken", err)
+	}
+}
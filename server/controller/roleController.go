@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"errors"
+	. "minisAPI/models"
+	"slices"
+
+	_ "github.com/go-sql-driver/mysql"
+)
+
+var (
+	ErrUnknownRole       = errors.New("Unbekannte Rolle")
+	ErrRoleNotAssignable = errors.New("Diese Rolle hat Rechte, die du selbst nicht hast")
+	ErrUserNotManageable = errors.New("Diese Person hat Rechte, die du selbst nicht hast")
+)
+
+func GetRoles() []Role {
+	results := ExecuteSQL("SELECT id, role_key, name FROM role ORDER BY id")
+	roles := []Role{}
+	for results.Next() {
+		var role Role
+		results.Scan(&role.Id, &role.Key, &role.Name)
+		role.Permissions = GetPermissionsForRole(role.Id)
+		roles = append(roles, role)
+	}
+	return roles
+}
+
+func GetPermissionsForRole(roleId int) []string {
+	results := ExecuteSQL("SELECT permission FROM role_permission WHERE role_id = ? ORDER BY permission", roleId)
+	permissions := []string{}
+	if results == nil {
+		return permissions
+	}
+	for results.Next() {
+		var permission string
+		results.Scan(&permission)
+		permissions = append(permissions, permission)
+	}
+	return permissions
+}
+
+func RoleHasPermission(roleId int, permission string) bool {
+	var allowed bool
+	ExecuteSQLRow("SELECT COUNT(*) FROM role_permission WHERE role_id = ? AND permission = ?", roleId, permission).Scan(&allowed)
+	return allowed
+}
+
+// CheckAssignableRole makes sure the role exists and grants no permission
+// the caller lacks, so nobody can create users more powerful than themselves.
+func CheckAssignableRole(roleId int, callerPermissions []string) error {
+	var exists bool
+	ExecuteSQLRow("SELECT COUNT(*) FROM role WHERE id = ?", roleId).Scan(&exists)
+	if !exists {
+		return ErrUnknownRole
+	}
+	for _, permission := range GetPermissionsForRole(roleId) {
+		if !slices.Contains(callerPermissions, permission) {
+			return ErrRoleNotAssignable
+		}
+	}
+	return nil
+}
+
+// CheckManageableUser applies CheckAssignableRole to the role of an existing
+// user, so nobody can take over or remove an account with more rights.
+// Unknown users pass, the handler answers them with 404.
+func CheckManageableUser(userId string, callerPermissions []string) error {
+	var roleId int
+	if err := ExecuteSQLRow("SELECT role_id FROM user WHERE id = ?", userId).Scan(&roleId); err != nil {
+		return nil
+	}
+	if err := CheckAssignableRole(roleId, callerPermissions); err != nil {
+		return ErrUserNotManageable
+	}
+	return nil
+}
+
+// SetRolePermissions replaces the permission set of a role.
+func SetRolePermissions(roleId string, permissions []string) error {
+	for _, permission := range permissions {
+		if !slices.Contains(AllPermissions, permission) {
+			return errors.New("unknown permission " + permission)
+		}
+	}
+
+	var exists bool
+	ExecuteSQLRow("SELECT COUNT(*) FROM role WHERE id = ?", roleId).Scan(&exists)
+	if !exists {
+		return errors.New("unknown role")
+	}
+
+	ExecuteDDL("DELETE FROM role_permission WHERE role_id = ?", roleId)
+	for _, permission := range permissions {
+		ExecuteDDL("INSERT INTO role_permission (role_id, permission) VALUES (?, ?)", roleId, permission)
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func expectTargetRole(mock sqlmock.Sqlmock, userId string, roleId int, permissions ...string) {
+	mock.ExpectQuery(`SELECT role_id FROM user WHERE id = \?`).WithArgs(userId).
+		WillReturnRows(sqlmock.NewRows([]string{"role_id"}).AddRow(roleId))
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM role WHERE id = \?`).WithArgs(roleId).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	rows := sqlmock.NewRows([]string{"permission"})
+	for _, permission := range permissions {
+		rows.AddRow(permission)
+	}
+	mock.ExpectQuery(`SELECT permission FROM role_permission WHERE role_id = \?`).WithArgs(roleId).WillReturnRows(rows)
+}
+
+func TestCheckManageableUserRefusesMorePowerfulTarget(t *testing.T) {
+	mock := withMockDB(t)
+	expectTargetRole(mock, "1", 3, "roles.manage", "users.manage")
+
+	if err := CheckManageableUser("1", []string{"users.manage"}); err != ErrUserNotManageable {
+		t.Errorf("err = %v, want ErrUserNotManageable", err)
+	}
+}
+
+func TestCheckManageableUserAllowsEqualTarget(t *testing.T) {
+	mock := withMockDB(t)
+	expectTargetRole(mock, "2", 1)
+
+	if err := CheckManageableUser("2", []string{"users.manage"}); err != nil {
+		t.Errorf("err = %v, want nil", err)
+	}
+}
@@ -0,0 +1,123 @@
+package controller
+
+import (
+	"errors"
+	"log"
+	. "minisAPI/models"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	accessTokenValidity  = 15 * time.Minute
+	refreshTokenValidity = 30 * 24 * time.Hour
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("Die Sitzung ist abgelaufen, bitte melde dich neu an")
+	ErrSessionRevoked      = errors.New("session revoked")
+)
+
+// startSession creates a server-side session for the user and returns a
+// short-lived access token together with the first refresh token.
+func startSession(user User, c *gin.Context) (AccessToken, error) {
+	refreshToken, err := generateToken()
+	if err != nil {
+		return AccessToken{}, err
+	}
+
+	userAgent := c.Request.UserAgent()
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+	result, err := db.Exec("INSERT INTO session (user_id, refresh_hash, expires_at, ip, user_agent) VALUES (?, ?, ?, ?, ?)",
+		user.Id, hashToken(refreshToken), time.Now().Add(refreshTokenValidity), c.ClientIP(), userAgent)
+	if err != nil {
+		return AccessToken{}, err
+	}
+	sessionId, _ := result.LastInsertId()
+
+	accessToken, err := signAccessToken(user, int(sessionId))
+	if err != nil {
+		return AccessToken{}, err
+	}
+	return AccessToken{AccessToken: accessToken, RefreshToken: refreshToken, ExpiresIn: int(accessTokenValidity.Seconds())}, nil
+}
+
+// RefreshSession exchanges a refresh token for a new access token and a new
+// refresh token. Each refresh token can be used once; presenting one that was
+// already rotated means it leaked, so the whole session is revoked.
+func RefreshSession(refreshToken string) (AccessToken, error) {
+	hash := hashToken(refreshToken)
+
+	var sessionId, userId int
+	var valid bool
+	err := ExecuteSQLRow(`SELECT s.id, s.user_id, s.revoked_at IS NULL AND s.expires_at > NOW() AND u.active = 1
+		FROM session s INNER JOIN user u ON u.id = s.user_id
+		WHERE s.refresh_hash = ?`, hash).Scan(&sessionId, &userId, &valid)
+	if err != nil {
+		var reusedId int
+		if ExecuteSQLRow("SELECT id FROM session WHERE previous_hash = ? AND revoked_at IS NULL", hash).Scan(&reusedId) == nil {
+			RevokeSession(reusedId)
+			log.Printf("refresh token of session %d was reused, session revoked", reusedId)
+		}
+		return AccessToken{}, ErrInvalidRefreshToken
+	}
+	if !valid {
+		return AccessToken{}, ErrInvalidRefreshToken
+	}
+
+	newToken, err := generateToken()
+	if err != nil {
+		return AccessToken{}, err
+	}
+	result := ExecuteDDL(`UPDATE session SET previous_hash = refresh_hash, refresh_hash = ?, last_used_at = NOW()
+		WHERE id = ? AND refresh_hash = ?`, hashToken(newToken), sessionId, hash)
+	if result == nil {
+		return AccessToken{}, ErrInvalidRefreshToken
+	}
+	if count, _ := result.RowsAffected(); count == 0 {
+		// the token was rotated concurrently
+		return AccessToken{}, ErrInvalidRefreshToken
+	}
+
+	accessToken, err := signAccessToken(GetUser(strconv.Itoa(userId)), sessionId)
+	if err != nil {
+		return AccessToken{}, err
+	}
+	return AccessToken{AccessToken: accessToken, RefreshToken: newToken, ExpiresIn: int(accessTokenValidity.Seconds())}, nil
+}
+
+func signAccessToken(user User, sessionId int) (string, error) {
+	now := time.Now()
+	t := jwt.NewWithClaims(jwt.SigningMethodHS256, TokenClaims{
+		Username:  user.Username,
+		UserId:    user.Id,
+		RoleId:    user.RoleId,
+		SessionId: sessionId,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.Itoa(user.Id),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(accessTokenValidity)),
+		},
+	})
+	return t.SignedString(jwtSecret)
+}
+
+func RevokeSession(sessionId int) {
+	ExecuteDDL("UPDATE session SET revoked_at = NOW() WHERE id = ? AND revoked_at IS NULL", sessionId)
+}
+
+// RevokeUserSessions logs the user out on all devices and returns the number
+// of revoked sessions.
+func RevokeUserSessions(userId string) int {
+	result := ExecuteDDL("UPDATE session SET revoked_at = NOW() WHERE user_id = ? AND revoked_at IS NULL", userId)
+	if result == nil {
+		return 0
+	}
+	count, _ := result.RowsAffected()
+	return int(count)
+}
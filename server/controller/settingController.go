@@ -0,0 +1,38 @@
+package controller
+
+import (
+	. "minisAPI/models"
+
+	_ "github.com/go-sql-driver/mysql"
+)
+
+func GetSettings() []Setting {
+	results := ExecuteSQL("SELECT setting_key, value FROM setting ORDER BY setting_key")
+	settings := []Setting{}
+	for results.Next() {
+		var setting Setting
+		results.Scan(&setting.Key, &setting.Value)
+		settings = append(settings, setting)
+	}
+	return settings
+}
+
+func GetSetting(key string) string {
+	var value string
+	ExecuteSQLRow("SELECT value FROM setting WHERE setting_key = ?", key).Scan(&value)
+	return value
+}
+
+func GetSettingBool(key string) bool {
+	return GetSetting(key) == "1"
+}
+
+func UpdateSetting(key string, value string) bool {
+	var exists bool
+	ExecuteSQLRow("SELECT COUNT(*) FROM setting WHERE setting_key = ?", key).Scan(&exists)
+	if !exists {
+		return false
+	}
+	ExecuteDDL("UPDATE setting SET value = ? WHERE setting_key = ?", value, key)
+	return true
+}
@@ -0,0 +1,160 @@
+package controller
+
+import (
+	"errors"
+	. "minisAPI/models"
+	"strconv"
+
+	_ "github.com/go-sql-driver/mysql"
+)
+
+var (
+	ErrBanRequestNotFound = errors.New("Änderungsantrag nicht gefunden")
+	ErrSurveyNotFound     = errors.New("Abfrage nicht gefunden")
+	ErrSurveyClosed       = errors.New("Die Abgabefrist dieser Abfrage ist vorbei")
+)
+
+func GetSurveys() []AvailabilitySurvey {
+	results := ExecuteSQL(`SELECT s.id, DATE_FORMAT(s.date_from, '%Y-%m-%d'), DATE_FORMAT(s.date_to, '%Y-%m-%d'), DATE_FORMAT(s.deadline, '%Y-%m-%d'),
+		(SELECT COUNT(*) FROM availability_response r WHERE r.survey_id = s.id),
+		(SELECT COUNT(*) FROM user WHERE active = 1 AND ` + servingRoles + `),
+		s.deadline < CURDATE()
+		FROM availability_survey s
+		ORDER BY s.date_from DESC`)
+	list := []AvailabilitySurvey{}
+	for results.Next() {
+		var survey AvailabilitySurvey
+		results.Scan(&survey.Id, &survey.DateFrom, &survey.DateTo, &survey.Deadline, &survey.Responded, &survey.Expected, &survey.Closed)
+		list = append(list, survey)
+	}
+	return list
+}
+
+func CreateSurvey(survey AvailabilitySurvey, createdBy int) (int, error) {
+	if err := validateAbsence(Absence{DateFrom: survey.DateFrom, DateTo: survey.DateTo}); err != nil {
+		return 0, err
+	}
+	if err := validateAbsence(Absence{DateFrom: survey.Deadline, DateTo: survey.DateTo}); err != nil {
+		return 0, errors.New("Die Frist muss vor dem Ende des Zeitraums liegen")
+	}
+
+	result, err := db.Exec("INSERT INTO availability_survey (date_from, date_to, deadline, created_by) VALUES (?, ?, ?, ?)",
+		survey.DateFrom, survey.DateTo, survey.Deadline, createdBy)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+func DeleteSurvey(surveyId string) {
+	ExecuteDDL("DELETE FROM availability_survey WHERE id = ?", surveyId)
+}
+
+// GetMissingResponses lists the active servers that have not confirmed the survey yet.
+func GetMissingResponses(surveyId string) []UserSmall {
+	results := ExecuteSQL(`SELECT u.id, u.firstname, u.lastname FROM user u
+		WHERE u.active = 1 AND u.`+servingRoles+`
+		AND NOT EXISTS (SELECT 1 FROM availability_response r WHERE r.survey_id = ? AND r.user_id = u.id)
+		ORDER BY u.lastname, u.firstname`, surveyId)
+	users := []UserSmall{}
+	for results.Next() {
+		var user UserSmall
+		results.Scan(&user.Id, &user.Firstname, &user.Lastname)
+		users = append(users, user)
+	}
+	return users
+}
+
+// SubmitSurvey marks the survey as answered by the user. Submitting twice is
+// fine, submitting after the deadline is not.
+func SubmitSurvey(surveyId string, userId string) error {
+	var open bool
+	if err := ExecuteSQLRow("SELECT deadline >= CURDATE() FROM availability_survey WHERE id = ?", surveyId).Scan(&open); err != nil {
+		return ErrSurveyNotFound
+	}
+	if !open {
+		return ErrSurveyClosed
+	}
+	_, err := db.Exec(`INSERT INTO availability_response (survey_id, user_id) VALUES (?, ?)
+		ON DUPLICATE KEY UPDATE submitted_at = submitted_at`, surveyId, userId)
+	return err
+}
+
+// lockedSurveyFor returns the survey whose deadline has passed and whose
+// period overlaps the given range. Changes of bans inside such a period need
+// the approval of an admin.
+func lockedSurveyFor(dateFrom string, dateTo string) (int, bool) {
+	var surveyId int
+	err := ExecuteSQLRow(`SELECT id FROM availability_survey
+		WHERE deadline < CURDATE() AND date_from <= ? AND date_to >= ?
+		ORDER BY deadline DESC LIMIT 1`, dateTo, dateFrom).Scan(&surveyId)
+	return surveyId, err == nil
+}
+
+// ChangeBanDate adds or removes a single ban date. Outside a locked period or
+// with direct set the change is applied immediately; otherwise it is stored as
+// a request and pending is true.
+func ChangeBanDate(userId string, update SingleBanDateUpdate, direct bool) (pending bool) {
+	if !direct {
+		if surveyId, locked := lockedSurveyFor(update.Date, update.Date); locked {
+			ExecuteDDL(`INSERT INTO ban_change_request (user_id, survey_id, kind, add_change, date_from, date_to)
+				VALUES (?, ?, 'ban', ?, ?, ?)`, userId, surveyId, update.Add, update.Date, update.Date)
+			return true
+		}
+	}
+
+	if update.Add {
+		AddBlockDate(userId, update.Date)
+	} else {
+		RemoveBlockDate(userId, update.Date)
+	}
+	return false
+}
+
+func GetBanChangeRequests(status string) []BanChangeRequest {
+	results := ExecuteSQL(`SELECT r.id, r.user_id, CONCAT(u.firstname, ' ', u.lastname), r.survey_id, r.kind, r.add_change,
+		IFNULL(DATE_FORMAT(r.date_from, '%Y-%m-%d'), ''), IFNULL(DATE_FORMAT(r.date_to, '%Y-%m-%d'), ''), IFNULL(r.reason, ''),
+		r.absence_id, r.status, r.created_at
+		FROM ban_change_request r
+		INNER JOIN user u ON u.id = r.user_id
+		WHERE r.status = ?
+		ORDER BY r.created_at`, status)
+	list := []BanChangeRequest{}
+	for results.Next() {
+		var request BanChangeRequest
+		results.Scan(&request.Id, &request.UserId, &request.Name, &request.SurveyId, &request.Kind, &request.Add,
+			&request.DateFrom, &request.DateTo, &request.Reason, &request.AbsenceId, &request.Status, &request.CreatedAt)
+		list = append(list, request)
+	}
+	return list
+}
+
+// DecideBanChangeRequest applies an approved request or just closes a rejected one.
+func DecideBanChangeRequest(requestId string, approve bool, deciderId int) error {
+	var request BanChangeRequest
+	err := ExecuteSQLRow(`SELECT id, user_id, kind, add_change, IFNULL(DATE_FORMAT(date_from, '%Y-%m-%d'), ''),
+		IFNULL(DATE_FORMAT(date_to, '%Y-%m-%d'), ''), IFNULL(reason, ''), absence_id
+		FROM ban_change_request WHERE id = ? AND status = 'pending'`, requestId).
+		Scan(&request.Id, &request.UserId, &request.Kind, &request.Add, &request.DateFrom, &request.DateTo, &request.Reason, &request.AbsenceId)
+	if err != nil {
+		return ErrBanRequestNotFound
+	}
+
+	status := "rejected"
+	if approve {
+		status = "approved"
+		userId := strconv.Itoa(request.UserId)
+		switch {
+		case request.Kind == "ban":
+			ChangeBanDate(userId, SingleBanDateUpdate{Date: request.DateFrom, Add: request.Add}, true)
+		case request.Add:
+			UpdateAbsences(userId, AbsenceUpdate{Add: []Absence{{DateFrom: request.DateFrom, DateTo: request.DateTo, Reason: request.Reason}}}, true)
+		case request.AbsenceId != nil:
+			UpdateAbsences(userId, AbsenceUpdate{Remove: []int{*request.AbsenceId}}, true)
+		}
+	}
+
+	ExecuteDDL("UPDATE ban_change_request SET status = ?, decided_by = ?, decided_at = NOW() WHERE id = ?", status, deciderId, request.Id)
+	return nil
+}
@@ -0,0 +1,19 @@
+package controller
+
+import (
+	"errors"
+	. "minisAPI/models"
+	"testing"
+)
+
+func TestCreateSurveyReturnsInsertError(t *testing.T) {
+	mock := withMockDB(t)
+	mock.ExpectExec(`INSERT INTO availability_survey \(date_from, date_to, deadline, created_by\) VALUES \(\?, \?, \?, \?\)`).
+		WithArgs("2026-12-01", "2026-12-31", "2026-11-20", 3).
+		WillReturnError(errors.New("connection lost"))
+
+	survey := AvailabilitySurvey{DateFrom: "2026-12-01", DateTo: "2026-12-31", Deadline: "2026-11-20"}
+	if _, err := CreateSurvey(survey, 3); err == nil {
+		t.Error("failed insert was not reported")
+	}
+}
@@ -0,0 +1,249 @@
+package controller
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	. "minisAPI/models"
+)
+
+var (
+	ErrSwapNotFound    = errors.New("Tauschanfrage nicht gefunden")
+	ErrSwapNotAllowed  = errors.New("Diese Aktion ist für die Tauschanfrage nicht möglich")
+	ErrSwapNotAssigned = errors.New("Du bist für diesen Termin nicht eingeteilt")
+	ErrSwapEventClosed = errors.New("Der Termin ist nicht veröffentlicht oder schon vorbei")
+	ErrSwapNotServing  = errors.New("Du kannst keine Dienste übernehmen")
+)
+
+const swapSelect = `SELECT s.id, s.event_id, e.name, DATE_FORMAT(e.date_begin, '%Y-%m-%d'), TIME_FORMAT(e.time_begin, '%H:%i:%s'), l.name,
+	s.from_user_id, CONCAT(fu.firstname, ' ', fu.lastname), s.to_user_id, IFNULL(CONCAT(tu.firstname, ' ', tu.lastname), ''),
+	s.status, s.created_at
+	FROM swap_request s
+	INNER JOIN event e ON e.id = s.event_id
+	INNER JOIN location l ON l.id = e.location_id
+	INNER JOIN user fu ON fu.id = s.from_user_id
+	LEFT JOIN user tu ON tu.id = s.to_user_id`
+
+// GetSwapRequests lists open offers for published, upcoming events of all
+// servers and every request the user is part of. With all set (planners)
+// every request is returned.
+func GetSwapRequests(userId int, all bool) []SwapRequest {
+	results := ExecuteSQL(swapSelect+`
+		WHERE ? OR (s.status = 'open' AND e.status = 'published' AND e.date_begin >= CURDATE())
+		OR s.from_user_id = ? OR s.to_user_id = ?
+		ORDER BY e.date_begin, e.time_begin, s.id`, all, userId, userId)
+	list := []SwapRequest{}
+	for results.Next() {
+		list = append(list, scanSwapRequest(results))
+	}
+	return list
+}
+
+func GetSwapRequest(swapId string) (SwapRequest, error) {
+	results := ExecuteSQL(swapSelect+" WHERE s.id = ?", swapId)
+	if results == nil {
+		return SwapRequest{}, ErrSwapNotFound
+	}
+	defer results.Close()
+	if !results.Next() {
+		return SwapRequest{}, ErrSwapNotFound
+	}
+	return scanSwapRequest(results), nil
+}
+
+// OfferSwap puts an assignment of the user up for swapping.
+func OfferSwap(userId int, eventId int) (int, error) {
+	var assigned bool
+	ExecuteSQLRow(`SELECT COUNT(*) FROM plan p
+		INNER JOIN event e ON e.id = p.event_id
+		WHERE p.user_id = ? AND p.event_id = ? AND e.status <> 'cancelled' AND e.date_begin >= CURDATE()`, userId, eventId).Scan(&assigned)
+	if !assigned {
+		return 0, ErrSwapNotAssigned
+	}
+
+	var open bool
+	ExecuteSQLRow("SELECT COUNT(*) FROM swap_request WHERE event_id = ? AND from_user_id = ? AND status IN ('open', 'pending_approval')",
+		eventId, userId).Scan(&open)
+	if open {
+		return 0, errors.New("Für diesen Termin gibt es bereits eine offene Tauschanfrage")
+	}
+
+	result, err := db.Exec("INSERT INTO swap_request (event_id, from_user_id) VALUES (?, ?)", eventId, userId)
+	if err != nil {
+		return 0, err
+	}
+	id, _ := result.LastInsertId()
+	return int(id), nil
+}
+
+// AcceptSwap lets another server take over an offered assignment. The taker
+// is checked against bans, weekdays and experience just like in the
+// assignment options. Without required approval the plan is changed right away.
+func AcceptSwap(swapId string, userId int) (string, error) {
+	swap, err := GetSwapRequest(swapId)
+	if err != nil {
+		return "", err
+	}
+	if swap.Status != SwapStatusOpen || swap.FromUserId == userId {
+		return "", ErrSwapNotAllowed
+	}
+	if err := checkSwapCandidate(db, swap.EventId, userId); err != nil {
+		return "", err
+	}
+
+	if GetSettingBool(SettingSwapRequiresApproval) {
+		result := ExecuteDDL("UPDATE swap_request SET to_user_id = ?, status = 'pending_approval' WHERE id = ? AND status = 'open'", userId, swap.Id)
+		if result == nil {
+			return "", ErrSwapNotAllowed
+		}
+		if count, _ := result.RowsAffected(); count == 0 {
+			return "", ErrSwapNotAllowed
+		}
+		return SwapStatusPendingApproval, nil
+	}
+
+	if err := completeSwap(swap.Id, SwapStatusOpen, userId, nil); err != nil {
+		return "", err
+	}
+	return SwapStatusCompleted, nil
+}
+
+// DecideSwap approves or rejects a swap that is waiting for a planner.
+func DecideSwap(swapId string, approve bool, deciderId int) error {
+	swap, err := GetSwapRequest(swapId)
+	if err != nil {
+		return err
+	}
+	if swap.Status != SwapStatusPendingApproval || swap.ToUserId == nil {
+		return ErrSwapNotAllowed
+	}
+
+	if !approve {
+		ExecuteDDL("UPDATE swap_request SET status = 'rejected', decided_by = ?, decided_at = NOW() WHERE id = ?", deciderId, swap.Id)
+		return nil
+	}
+	return completeSwap(swap.Id, SwapStatusPendingApproval, *swap.ToUserId, &deciderId)
+}
+
+// CancelSwap withdraws an offer as long as the plan was not changed yet.
+func CancelSwap(swapId string, userId int) error {
+	result := ExecuteDDL("UPDATE swap_request SET status = 'cancelled' WHERE id = ? AND from_user_id = ? AND status IN ('open', 'pending_approval')",
+		swapId, userId)
+	if result == nil {
+		return ErrSwapNotAllowed
+	}
+	if count, _ := result.RowsAffected(); count == 0 {
+		return ErrSwapNotAllowed
+	}
+	return nil
+}
+
+// checkSwapCandidate makes sure the event is published and still ahead and
+// the taker is an active, approved server who is free on that day.
+func checkSwapCandidate(q rowQuerier, eventId int, userId int) error {
+	var dateBegin, category string
+	var ignoreWeekday, open bool
+	err := q.QueryRow(`SELECT DATE_FORMAT(date_begin, '%Y-%m-%d'), IFNULL(ignoreWeekday, 0), category,
+		status = 'published' AND date_begin >= CURDATE() FROM event WHERE id = ?`, eventId).
+		Scan(&dateBegin, &ignoreWeekday, &category, &open)
+	if err != nil {
+		return err
+	}
+	if !open {
+		return ErrSwapEventClosed
+	}
+
+	var serving bool
+	q.QueryRow("SELECT COUNT(*) FROM user WHERE id = ? AND active = 1 AND "+assignableUsers, userId).Scan(&serving)
+	if !serving {
+		return ErrSwapNotServing
+	}
+
+	var assigned bool
+	q.QueryRow("SELECT COUNT(*) FROM plan WHERE event_id = ? AND user_id = ?", eventId, userId).Scan(&assigned)
+	if assigned {
+		return errors.New("Du bist für diesen Termin bereits eingeteilt")
+	}
+
+	status, err := getAvailabilityStatus(q, userId, dateBegin, ignoreWeekday, category)
+	if err != nil {
+		return err
+	}
+	if status != "ok" {
+		return errors.New(getAvailabilityReason(status))
+	}
+	return nil
+}
+
+// completeSwap moves the plan row from the offering to the taking user and
+// closes the request in one transaction. The new row keeps the status of the
+// old one, so a published assignment stays published.
+func completeSwap(swapId int, expectedStatus string, toUserId int, deciderId *int) error {
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin txn: %w", err)
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			if rerr := tx.Rollback(); rerr != nil && rerr != sql.ErrTxDone {
+				log.Printf("rollback failed: %v", rerr)
+			}
+		}
+	}()
+
+	var eventId, fromUserId int
+	var status string
+	err = tx.QueryRowContext(ctx, "SELECT event_id, from_user_id, status FROM swap_request WHERE id = ? FOR UPDATE", swapId).
+		Scan(&eventId, &fromUserId, &status)
+	if err != nil {
+		return ErrSwapNotFound
+	}
+	if status != expectedStatus {
+		return ErrSwapNotAllowed
+	}
+
+	// the availability may have changed while the request was waiting
+	if err := checkSwapCandidate(tx, eventId, toUserId); err != nil {
+		return err
+	}
+
+	var planStatus string
+	err = tx.QueryRowContext(ctx, "SELECT status FROM plan WHERE event_id = ? AND user_id = ? FOR UPDATE", eventId, fromUserId).Scan(&planStatus)
+	if err != nil {
+		return ErrSwapNotAssigned
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM plan WHERE event_id = ? AND user_id = ?", eventId, fromUserId); err != nil {
+		return fmt.Errorf("remove assignment: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO plan (user_id, event_id, status) VALUES (?, ?, ?)", toUserId, eventId, planStatus); err != nil {
+		return fmt.Errorf("add assignment: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE swap_request SET to_user_id = ?, status = 'completed', decided_by = ?, decided_at = NOW() WHERE id = ?",
+		toUserId, deciderId, swapId); err != nil {
+		return fmt.Errorf("close swap request: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	committed = true
+	log.Printf("Swap %d completed: event %d from user %d to user %d", swapId, eventId, fromUserId, toUserId)
+	return nil
+}
+
+func scanSwapRequest(results *sql.Rows) SwapRequest {
+	var swap SwapRequest
+	var toUserId sql.NullInt64
+	results.Scan(&swap.Id, &swap.EventId, &swap.EventName, &swap.DateBegin, &swap.TimeBegin, &swap.Location,
+		&swap.FromUserId, &swap.FromName, &toUserId, &swap.ToName, &swap.Status, &swap.CreatedAt)
+	if toUserId.Valid {
+		v := int(toUserId.Int64)
+		swap.ToUserId = &v
+	}
+	return swap
+}
@@ -0,0 +1,34 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func expectSwapEvent(mock sqlmock.Sqlmock, eventId int, open bool) {
+	mock.ExpectQuery(`SELECT DATE_FORMAT\(date_begin, '%Y-%m-%d'\), IFNULL\(ignoreWeekday, 0\), category,\s+status = 'published' AND date_begin >= CURDATE\(\) FROM event WHERE id = \?`).
+		WithArgs(eventId).
+		WillReturnRows(sqlmock.NewRows([]string{"date_begin", "ignoreWeekday", "category", "open"}).AddRow("2026-11-01", false, "mass", open))
+}
+
+func TestCheckSwapCandidateRefusesClosedEvent(t *testing.T) {
+	mock := withMockDB(t)
+	expectSwapEvent(mock, 3, false)
+
+	if err := checkSwapCandidate(db, 3, 9); err != ErrSwapEventClosed {
+		t.Errorf("err = %v, want ErrSwapEventClosed", err)
+	}
+}
+
+func TestCheckSwapCandidateRefusesNonServingUser(t *testing.T) {
+	mock := withMockDB(t)
+	expectSwapEvent(mock, 3, true)
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM user WHERE id = \? AND active = 1 AND role_id in \(1, 2, 4\) AND registration_status = 'approved' AND erased_at IS NULL`).
+		WithArgs(9).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	if err := checkSwapCandidate(db, 3, 9); err != ErrSwapNotServing {
+		t.Errorf("err = %v, want ErrSwapNotServing", err)
+	}
+}
@@ -0,0 +1,190 @@
+package controller
+
+import (
+	"database/sql"
+	"errors"
+	. "minisAPI/models"
+	"strings"
+
+	_ "github.com/go-sql-driver/mysql"
+)
+
+var ErrTemplateNameMissing = errors.New("Die Vorlage braucht einen Namen")
+
+func GetTemplates() []EventTemplate {
+	statement := `select t.id, t.name, t.event_name, TIME_FORMAT(t.time_begin, '%H:%i:%s'), t.location_id, l.name,
+        t.minimalUser, t.ignoreWeekday, t.category
+        from event_template t
+        inner join location l on l.id = t.location_id
+        order by t.name`
+
+	results := ExecuteSQL(statement)
+	templates := []EventTemplate{}
+	for results.Next() {
+		var template EventTemplate
+		results.Scan(&template.Id, &template.Name, &template.EventName, &template.TimeBegin, &template.LocationID,
+			&template.Location, &template.MinimalUser, &template.IgnoreWeekday, &template.Category)
+		template.Duties = getTemplateDuties(template.Id)
+		templates = append(templates, template)
+	}
+	return templates
+}
+
+func GetTemplate(templateId string) (EventTemplate, error) {
+	statement := `select t.id, t.name, t.event_name, TIME_FORMAT(t.time_begin, '%H:%i:%s'), t.location_id, l.name,
+        t.minimalUser, t.ignoreWeekday, t.category
+        from event_template t
+        inner join location l on l.id = t.location_id
+        where t.id = ?`
+
+	var template EventTemplate
+	err := ExecuteSQLRow(statement, templateId).Scan(&template.Id, &template.Name, &template.EventName, &template.TimeBegin,
+		&template.LocationID, &template.Location, &template.MinimalUser, &template.IgnoreWeekday, &template.Category)
+	if err != nil {
+		return EventTemplate{}, err
+	}
+	template.Duties = getTemplateDuties(template.Id)
+	return template, nil
+}
+
+func CreateTemplate(template EventTemplate) (int, error) {
+	if strings.TrimSpace(template.Name) == "" {
+		return 0, ErrTemplateNameMissing
+	}
+	if !IsLocationActive(template.LocationID) {
+		return 0, ErrLocationRetired
+	}
+	statement := `
+        INSERT INTO event_template (name, event_name, time_begin, location_id, minimalUser, ignoreWeekday, category)
+        VALUES (?, ?, ?, ?, ?, ?, ?)
+    `
+	result, err := db.Exec(
+		statement,
+		template.Name,
+		template.EventName,
+		template.TimeBegin,
+		template.LocationID,
+		template.MinimalUser,
+		template.IgnoreWeekday,
+		categoryOrDefault(template.Category),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	id, _ := result.LastInsertId()
+	setTemplateDuties(int(id), template.Duties)
+	return int(id), nil
+}
+
+// UpdateTemplate applies a partial update and returns sql.ErrNoRows for an
+// unknown template. Duties are only replaced when they are sent.
+func UpdateTemplate(templateId string, update EventTemplateUpdate) error {
+	var id int
+	if err := ExecuteSQLRow("SELECT id FROM event_template WHERE id = ?", templateId).Scan(&id); err != nil {
+		return sql.ErrNoRows
+	}
+	if update.Name != nil && strings.TrimSpace(*update.Name) == "" {
+		return ErrTemplateNameMissing
+	}
+	if update.LocationID != nil && !IsLocationActive(*update.LocationID) {
+		return ErrLocationRetired
+	}
+	if update.Category != nil {
+		category := categoryOrDefault(*update.Category)
+		update.Category = &category
+	}
+
+	_, err := db.Exec(`UPDATE event_template SET name = COALESCE(?, name), event_name = COALESCE(?, event_name),
+		time_begin = COALESCE(?, time_begin), location_id = COALESCE(?, location_id), minimalUser = COALESCE(?, minimalUser),
+		ignoreWeekday = COALESCE(?, ignoreWeekday), category = COALESCE(?, category) WHERE id = ?`,
+		update.Name, update.EventName, update.TimeBegin, update.LocationID, update.MinimalUser, update.IgnoreWeekday,
+		update.Category, id)
+	if err != nil {
+		return err
+	}
+	if update.Duties != nil {
+		setTemplateDuties(id, *update.Duties)
+	}
+	return nil
+}
+
+func DeleteTemplate(templateId string) {
+	ExecuteDDL("DELETE FROM event_template WHERE id = ?", templateId)
+}
+
+// CreateEventsFromTemplate creates an event for every given date with the
+// defaults of the template and the duties copied onto each event.
+func CreateEventsFromTemplate(templateId string, payload EventFromTemplate) ([]int, error) {
+	template, err := GetTemplate(templateId)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload.Dates) == 0 {
+		return nil, errors.New("no dates given")
+	}
+
+	ev := Event{
+		Name:          template.EventName,
+		TimeBegin:     template.TimeBegin,
+		LocationID:    template.LocationID,
+		MinimalUser:   template.MinimalUser,
+		IgnoreWeekday: template.IgnoreWeekday,
+		Category:      template.Category,
+		TemplateId:    &template.Id,
+	}
+	if payload.Name != nil {
+		ev.Name = *payload.Name
+	}
+	if payload.TimeBegin != nil {
+		ev.TimeBegin = *payload.TimeBegin
+	}
+	if payload.LocationID != nil {
+		ev.LocationID = *payload.LocationID
+	}
+	if payload.MinimalUser != nil {
+		ev.MinimalUser = *payload.MinimalUser
+	}
+	if payload.IgnoreWeekday != nil {
+		ev.IgnoreWeekday = *payload.IgnoreWeekday
+	}
+	if payload.Category != nil {
+		ev.Category = *payload.Category
+	}
+	if !IsLocationActive(ev.LocationID) {
+		return nil, ErrLocationRetired
+	}
+
+	ids := []int{}
+	for _, date := range payload.Dates {
+		ev.DateBegin = date
+		id, err := CreateEvent(ev)
+		if err != nil {
+			return ids, err
+		}
+		for _, duty := range template.Duties {
+			ExecuteDDL("INSERT INTO event_duty (event_id, duty, count) VALUES (?, ?, ?)", id, duty.Duty, duty.Count)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func getTemplateDuties(templateId int) []EventDuty {
+	rows := ExecuteSQL("SELECT duty, count FROM event_template_duty WHERE template_id = ? ORDER BY duty", templateId)
+
+	list := []EventDuty{}
+	for rows.Next() {
+		var duty EventDuty
+		rows.Scan(&duty.Duty, &duty.Count)
+		list = append(list, duty)
+	}
+	return list
+}
+
+func setTemplateDuties(templateId int, duties []EventDuty) {
+	ExecuteDDL("DELETE FROM event_template_duty WHERE template_id = ?", templateId)
+	for _, duty := range duties {
+		ExecuteDDL("INSERT INTO event_template_duty (template_id, duty, count) VALUES (?, ?, ?)", templateId, duty.Duty, duty.Count)
+	}
+}
@@ -0,0 +1,108 @@
+package controller
+
+import (
+	"strings"
+	"time"
+)
+
+// Failed logins are counted per username and per IP address within
+// loginFailureWindow. Once a threshold is reached every further failure
+// doubles the waiting time, up to loginMaxLockout. Password reset mails and
+// registrations are throttled the same way, every request counts as a hit.
+const (
+	loginFailureWindow = 30 * time.Minute
+	loginBaseLockout   = time.Minute
+	loginMaxLockout    = time.Hour
+)
+
+// throttleRule names the kind of request and the number of hits per key
+// (username or login) and per IP after which requests have to wait. A key
+// threshold of 0 only throttles by IP.
+type throttleRule struct {
+	kind         string
+	keyThreshold int
+	ipThreshold  int
+}
+
+var (
+	loginThrottle         = throttleRule{kind: "login", keyThreshold: 5, ipThreshold: 20}
+	passwordResetThrottle = throttleRule{kind: "password_reset", keyThreshold: 3, ipThreshold: 10}
+	registrationThrottle  = throttleRule{kind: "registration", ipThreshold: 10}
+)
+
+// LoginLockedFor returns how long the next login attempt for the username or
+// from the IP has to wait. Zero means the attempt may proceed.
+func LoginLockedFor(username string, ip string) time.Duration {
+	return loginThrottle.lockedFor(username, ip)
+}
+
+// PasswordResetLockedFor works like LoginLockedFor for reset mails, so the
+// endpoint cannot be used to flood somebody's inbox.
+func PasswordResetLockedFor(login string, ip string) time.Duration {
+	return passwordResetThrottle.lockedFor(login, ip)
+}
+
+// RegistrationLockedFor returns how long the next registration from the IP
+// has to wait, so the invite code cannot be guessed and the sign-up cannot be
+// flooded.
+func RegistrationLockedFor(ip string) time.Duration {
+	return registrationThrottle.lockedFor("", ip)
+}
+
+func (rule throttleRule) lockedFor(key string, ip string) time.Duration {
+	var byKey time.Duration
+	if rule.keyThreshold > 0 {
+		byKey = rule.lockoutFor("UPPER(username) = UPPER(?)", strings.TrimSpace(key), rule.keyThreshold)
+	}
+	byIp := rule.lockoutFor("ip = ?", ip, rule.ipThreshold)
+	return max(byKey, byIp)
+}
+
+func (rule throttleRule) lockoutFor(condition string, value string, threshold int) time.Duration {
+	var failures, secondsSinceLast int
+	err := ExecuteSQLRow(`SELECT COUNT(*), IFNULL(TIMESTAMPDIFF(SECOND, MAX(failed_at), NOW()), 0) FROM login_failure
+		WHERE kind = ? AND `+condition+` AND failed_at > NOW() - INTERVAL ? SECOND`,
+		rule.kind, value, int(loginFailureWindow.Seconds())).Scan(&failures, &secondsSinceLast)
+	if err != nil || failures < threshold {
+		return 0
+	}
+
+	lockout := loginBaseLockout
+	for i := threshold; i < failures && lockout < loginMaxLockout; i++ {
+		lockout *= 2
+	}
+	lockout = min(lockout, loginMaxLockout)
+	return max(lockout-time.Duration(secondsSinceLast)*time.Second, 0)
+}
+
+func (rule throttleRule) record(key string, ip string) {
+	ExecuteDDL("DELETE FROM login_failure WHERE failed_at < NOW() - INTERVAL 1 DAY")
+	ExecuteDDL("INSERT INTO login_failure (kind, username, ip) VALUES (?, ?, ?)", rule.kind, strings.TrimSpace(key), ip)
+}
+
+func recordLoginFailure(username string, ip string) {
+	loginThrottle.record(username, ip)
+}
+
+func recordPasswordResetRequest(login string, ip string) {
+	passwordResetThrottle.record(login, ip)
+}
+
+func recordRegistrationAttempt(username string, ip string) {
+	registrationThrottle.record(username, ip)
+}
+
+func clearLoginFailures(username string) {
+	ExecuteDDL("DELETE FROM login_failure WHERE kind = ? AND UPPER(username) = UPPER(?)", loginThrottle.kind, strings.TrimSpace(username))
+}
+
+// UnlockUser removes the failed login attempts of the user, so they can log
+// in again right away.
+func UnlockUser(userId string) bool {
+	user := GetUser(userId)
+	if user.Id == 0 {
+		return false
+	}
+	clearLoginFailures(user.Username)
+	return true
+}
@@ -1,13 +1,37 @@
 package controller
 
 import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
 	. "minisAPI/models"
+	"strconv"
+	"strings"
 
 	_ "github.com/go-sql-driver/mysql"
 )
 
+// servingRoles restricts a user query to the roles that are assigned to events.
+const servingRoles = "role_id in (1, 2, 4)"
+
+// assignableUsers restricts a user query to approved, not erased users with a
+// serving role, i.e. everyone who may be put on a plan.
+const assignableUsers = servingRoles + " AND registration_status = 'approved' AND erased_at IS NULL"
+
+// defaultRoleId is the server role new users get when no role is given.
+const defaultRoleId = 1
+
+var (
+	ErrUsernameTaken      = errors.New("Benutzername ist bereits vergeben")
+	ErrUserFieldForbidden = errors.New("Aktiv, Weihrauch und Erfahrung dürfen nur Admins ändern")
+	ErrPreferredSelf      = errors.New("Man kann sich nicht selbst als Partner wählen")
+)
+
 func GetAllUserHead() []UserSmall {
-	results := ExecuteSQL("SELECT id, firstname, lastname FROM user WHERE active = 1 and role_id in (1, 2) ORDER BY lastname, firstname")
+	results := ExecuteSQL("SELECT id, firstname, lastname FROM user WHERE active = 1 and " + servingRoles + " ORDER BY lastname, firstname")
 	users := []UserSmall{}
 	for results.Next() {
 		var user UserSmall
@@ -18,11 +42,11 @@ func GetAllUserHead() []UserSmall {
 }
 
 func GetAllUser() []User {
-	results := ExecuteSQL("SELECT id, firstname, lastname, username, role_id, active, incense FROM user ORDER BY active DESC, lastname, firstname")
+	results := ExecuteSQL("SELECT id, firstname, lastname, username, role_id, active, incense, experience, IFNULL(email, '') FROM user WHERE registration_status = 'approved' ORDER BY active DESC, lastname, firstname")
 	users := []User{}
 	for results.Next() {
 		var user User
-		results.Scan(&user.Id, &user.Firstname, &user.Lastname, &user.Username, &user.RoleId, &user.Active, &user.Incense)
+		results.Scan(&user.Id, &user.Firstname, &user.Lastname, &user.Username, &user.RoleId, &user.Active, &user.Incense, &user.Experience, &user.Email)
 		users = append(users, user)
 	}
 	return users
@@ -30,36 +54,105 @@ func GetAllUser() []User {
 
 func GetUser(userId string) User {
 	var user User
-	ExecuteSQLRow("SELECT id, firstname, lastname, username, role_id, active, incense FROM user WHERE id = ?", userId).Scan(&user.Id, &user.Firstname, &user.Lastname, &user.Username, &user.RoleId, &user.Active, &user.Incense)
+	ExecuteSQLRow("SELECT id, firstname, lastname, username, role_id, active, incense, experience, IFNULL(email, '') FROM user WHERE id = ?", userId).Scan(&user.Id, &user.Firstname, &user.Lastname, &user.Username, &user.RoleId, &user.Active, &user.Incense, &user.Experience, &user.Email)
 	return user
 }
 
 func GetUserForUsername(username string) User {
 	var user User
-	ExecuteSQLRow("SELECT id, firstname, lastname, username, role_id, active, incense FROM user WHERE upper(username) = (?)", username).Scan(&user.Id, &user.Firstname, &user.Lastname, &user.Username, &user.RoleId, &user.Active, &user.Incense)
+	ExecuteSQLRow("SELECT id, firstname, lastname, username, role_id, active, incense, experience, IFNULL(email, '') FROM user WHERE upper(username) = (?)", username).Scan(&user.Id, &user.Firstname, &user.Lastname, &user.Username, &user.RoleId, &user.Active, &user.Incense, &user.Experience, &user.Email)
 	return user
 }
 
-func UpdateUser(userId string, user User) bool {
-	ExecuteDDL("UPDATE user SET firstname=?, lastname=?, active=?, incense=? WHERE id=?", user.Firstname, user.Lastname, user.Active, user.Incense, userId)
-	return true
+// UpdateUser applies a partial update. Without canManage, changes to the
+// active flag, incense or experience are refused; sending the stored value
+// again is fine so the own settings form keeps working.
+func UpdateUser(userId string, update UserUpdate, canManage bool) error {
+	current := GetUser(userId)
+	if current.Id == 0 {
+		return sql.ErrNoRows
+	}
+	if !canManage && (changesInt(update.Active, current.Active) || changesInt(update.Incense, current.Incense) || changesInt(update.Experience, current.Experience)) {
+		return ErrUserFieldForbidden
+	}
+
+	_, err := db.Exec(`UPDATE user SET firstname = COALESCE(?, firstname), lastname = COALESCE(?, lastname),
+		active = COALESCE(?, active), incense = COALESCE(?, incense), experience = COALESCE(?, experience),
+		email = IF(? IS NULL, email, NULLIF(?, '')) WHERE id = ?`,
+		update.Firstname, update.Lastname, update.Active, update.Incense, update.Experience, update.Email, update.Email, userId)
+	return err
 }
 
-func UpdatePassword(userId string, password string) bool {
-	ExecuteDDL("UPDATE user SET password=? WHERE id=?", password, userId)
-	return true
+func changesInt(value *int, current int) bool {
+	return value != nil && *value != current
+}
+
+func UpdatePassword(userId string, password string) error {
+	if err := ValidatePassword(password); err != nil {
+		return err
+	}
+	hash, err := HashPassword(password)
+	if err != nil {
+		return err
+	}
+	ExecuteDDL("UPDATE user SET password=? WHERE id=?", hash, userId)
+	return nil
+}
+
+// AddPreferredUser asks the other user to become a preferred partner. If the
+// other user already asked for the same, the pair is accepted right away.
+func AddPreferredUser(userId string, otherId int) error {
+	if userId == strconv.Itoa(otherId) {
+		return ErrPreferredSelf
+	}
+
+	var exists bool
+	ExecuteSQLRow(`SELECT COUNT(*) FROM preference_together
+		WHERE (user_id_1 = ? AND user_id_2 = ?) OR (user_id_1 = ? AND user_id_2 = ? AND status = 'accepted')`,
+		userId, otherId, otherId, userId).Scan(&exists)
+	if exists {
+		return nil
+	}
+
+	result, err := db.Exec("UPDATE preference_together SET status = 'accepted' WHERE user_id_1 = ? AND user_id_2 = ?", otherId, userId)
+	if err != nil {
+		return err
+	}
+	if count, _ := result.RowsAffected(); count > 0 {
+		return nil
+	}
+	_, err = db.Exec("INSERT INTO preference_together (user_id_1, user_id_2) VALUES (?, ?)", userId, otherId)
+	return err
 }
 
-func AddPreferredUser(userId string, otherId int) {
-	ExecuteDDL("INSERT INTO preference_together (user_id_1, user_id_2) VALUES (?, ?)", userId, otherId)
+// RemovePreferredUser withdraws a request or ends an accepted pair, no matter
+// which side created it.
+func RemovePreferredUser(userId string, otherId int) error {
+	_, err := db.Exec("DELETE FROM preference_together WHERE (user_id_1 = ? AND user_id_2 = ?) OR (user_id_1 = ? AND user_id_2 = ?)",
+		userId, otherId, otherId, userId)
+	return err
 }
 
-func RemovePreferredUser(userId string, otherId int) {
-	db.Exec("DELETE FROM preference_together WHERE user_id_1 = ? AND user_id_2 = ?", userId, otherId)
+// RespondPreferredRequest accepts or declines a request of another user.
+func RespondPreferredRequest(userId string, otherId int, accept bool) bool {
+	var result sql.Result
+	if accept {
+		result = ExecuteDDL("UPDATE preference_together SET status = 'accepted' WHERE user_id_1 = ? AND user_id_2 = ? AND status = 'pending'", otherId, userId)
+	} else {
+		result = ExecuteDDL("DELETE FROM preference_together WHERE user_id_1 = ? AND user_id_2 = ? AND status = 'pending'", otherId, userId)
+	}
+	if result == nil {
+		return false
+	}
+	count, _ := result.RowsAffected()
+	return count > 0
 }
 
+// GetPreferredUsers returns the partners the user asked for or is paired with.
 func GetPreferredUsers(userId string) []int {
-	results := ExecuteSQL("SELECT user_id_2 FROM preference_together WHERE user_id_1 = ?", userId)
+	results := ExecuteSQL(`SELECT user_id_2 FROM preference_together WHERE user_id_1 = ?
+		UNION
+		SELECT user_id_1 FROM preference_together WHERE user_id_2 = ? AND status = 'accepted'`, userId, userId)
 
 	var list []int
 	for results.Next() {
@@ -69,3 +162,155 @@ func GetPreferredUsers(userId string) []int {
 	}
 	return list
 }
+
+func GetPreferredOverview(userId string) PreferredOverview {
+	overview := PreferredOverview{Accepted: []UserSmall{}, Outgoing: []UserSmall{}, Incoming: []UserSmall{}}
+	results := ExecuteSQL(`SELECT u.id, u.firstname, u.lastname, p.status, p.user_id_1 = ?
+		FROM preference_together p
+		INNER JOIN user u ON u.id = IF(p.user_id_1 = ?, p.user_id_2, p.user_id_1)
+		WHERE p.user_id_1 = ? OR p.user_id_2 = ?
+		ORDER BY u.lastname, u.firstname`, userId, userId, userId, userId)
+	for results.Next() {
+		var user UserSmall
+		var status string
+		var outgoing bool
+		results.Scan(&user.Id, &user.Firstname, &user.Lastname, &status, &outgoing)
+		switch {
+		case status == "accepted":
+			overview.Accepted = append(overview.Accepted, user)
+		case outgoing:
+			overview.Outgoing = append(overview.Outgoing, user)
+		default:
+			overview.Incoming = append(overview.Incoming, user)
+		}
+	}
+	return overview
+}
+
+// CreateUser adds a new active user. Without a password a random initial
+// password is generated and returned so the admin can hand it out once.
+// Without a role the user becomes a server; roles granting permissions the
+// caller lacks are refused.
+func CreateUser(newUser NewUser, callerPermissions []string) (int, string, error) {
+	newUser.Username = strings.TrimSpace(newUser.Username)
+	if newUser.Username == "" || strings.TrimSpace(newUser.Firstname) == "" || strings.TrimSpace(newUser.Lastname) == "" {
+		return 0, "", errors.New("Vorname, Nachname und Benutzername sind erforderlich")
+	}
+	if UsernameExists(newUser.Username) {
+		return 0, "", ErrUsernameTaken
+	}
+	if newUser.RoleId == 0 {
+		newUser.RoleId = defaultRoleId
+	}
+	if err := CheckAssignableRole(newUser.RoleId, callerPermissions); err != nil {
+		return 0, "", err
+	}
+
+	initialPassword := ""
+	if newUser.Password == "" {
+		generated, err := generateInitialPassword()
+		if err != nil {
+			return 0, "", err
+		}
+		newUser.Password = generated
+		initialPassword = generated
+	} else if err := ValidatePassword(newUser.Password); err != nil {
+		return 0, "", err
+	}
+
+	hash, err := HashPassword(newUser.Password)
+	if err != nil {
+		return 0, "", err
+	}
+
+	result, err := db.Exec("INSERT INTO user (firstname, lastname, username, password, role_id, active, incense, experience, email) VALUES (?, ?, ?, ?, ?, 1, ?, ?, NULLIF(?, ''))",
+		newUser.Firstname, newUser.Lastname, newUser.Username, hash, newUser.RoleId, newUser.Incense, newUser.Experience, newUser.Email)
+	if err != nil {
+		return 0, "", err
+	}
+	id, _ := result.LastInsertId()
+	return int(id), initialPassword, nil
+}
+
+func UsernameExists(username string) bool {
+	var exists bool
+	ExecuteSQLRow("SELECT COUNT(*) FROM user WHERE UPPER(username) = UPPER(?)", username).Scan(&exists)
+	return exists
+}
+
+// DeactivateUser sets the user inactive and removes them from all events that
+// have not happened yet. It returns the number of removed assignments.
+func DeactivateUser(userId string) int {
+	ExecuteDDL("UPDATE user SET active = 0 WHERE id = ?", userId)
+	RevokeUserSessions(userId)
+	result := ExecuteDDL(`DELETE p FROM plan p
+		INNER JOIN event e ON e.id = p.event_id
+		WHERE p.user_id = ? AND e.date_begin >= CURDATE()`, userId)
+	if result == nil {
+		return 0
+	}
+	count, _ := result.RowsAffected()
+	return int(count)
+}
+
+// DeleteUser removes a user together with all rows that reference them in one
+// transaction, so a failing step leaves the user untouched.
+func DeleteUser(userId string) error {
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin txn: %w", err)
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			if rerr := tx.Rollback(); rerr != nil && rerr != sql.ErrTxDone {
+				log.Printf("rollback failed: %v", rerr)
+			}
+		}
+	}()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM preference_together WHERE user_id_1 = ? OR user_id_2 = ?", userId, userId); err != nil {
+		return fmt.Errorf("delete preferences: %w", err)
+	}
+	for _, table := range []string{"user_weekday", "ban", "plan"} {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE user_id = ?", userId); err != nil {
+			return fmt.Errorf("delete %s: %w", table, err)
+		}
+	}
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM user WHERE id = ?", userId)
+	if err != nil {
+		return fmt.Errorf("delete user: %w", err)
+	}
+	if count, _ := result.RowsAffected(); count == 0 {
+		return sql.ErrNoRows
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	committed = true
+	log.Printf("Deleted user %s", userId)
+	return nil
+}
+
+func generateInitialPassword() (string, error) {
+	const letters = "abcdefghjkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ"
+	const digits = "23456789"
+
+	buf := make([]byte, 10)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	password := make([]byte, len(buf))
+	for i, b := range buf {
+		// every third character is a digit so the password passes ValidatePassword
+		if i%3 == 2 {
+			password[i] = digits[int(b)%len(digits)]
+		} else {
+			password[i] = letters[int(b)%len(letters)]
+		}
+	}
+	return string(password), nil
+}
@@ -1,10 +1,17 @@
 package main
 
 import (
+	"database/sql"
+	"errors"
+	"io"
+	"log"
+	"math"
+	"minisAPI/config"
 	. "minisAPI/controller"
 	. "minisAPI/middleware"
 	. "minisAPI/models"
 	"net/http"
+	"slices"
 	"strconv"
 
 	"github.com/gin-contrib/cors"
@@ -12,56 +19,238 @@ import (
 )
 
 func main() {
-	InitDB()
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := InitDB(cfg.DatabaseDSN); err != nil {
+		log.Fatal(err)
+	}
 	defer CloseDB()
+	InitMail(cfg.Mail)
+	InitOIDC(cfg.OIDC)
+	SetJWTSecret(cfg.JWTSecret)
+	SetAppURL(cfg.AppURL)
+	SetPdfResources(PdfResources{FontPath: cfg.FontPath, FontBoldPath: cfg.FontBoldPath, LogoPath: cfg.LogoPath})
 
 	router := gin.Default()
-	config := cors.DefaultConfig()
-	config.AllowAllOrigins = true
-	config.AllowHeaders = []string{"Content-Type", "Content-Length", "Accept-Encoding", "Authorization", "Cache-Control"}
+	if err := trustProxies(router, cfg.TrustedProxies); err != nil {
+		log.Fatal(err)
+	}
+	corsConfig := cors.DefaultConfig()
+	if cfg.AllowsAllOrigins() {
+		corsConfig.AllowAllOrigins = true
+	} else {
+		corsConfig.AllowOrigins = cfg.CORSOrigins
+	}
+	corsConfig.AllowHeaders = []string{"Content-Type", "Content-Length", "Accept-Encoding", "Authorization", "Cache-Control"}
 
-	router.Use(cors.New(config))
+	router.Use(cors.New(corsConfig))
 	router.POST("/login", login)
+	router.POST("/password/forgot", forgotPassword)
+	router.POST("/password/reset", resetPassword)
+	router.POST("/register", register)
+	router.POST("/token/refresh", refreshToken)
+	router.GET("/oidc/config", getOIDCConfig)
+	router.GET("/oidc/login", oidcLogin)
+	router.GET("/oidc/callback", oidcCallback)
 
 	auth := router.Group("/")
 	auth.Use(AuthUser())
 	auth.GET("/checkToken", checkToken)
+	auth.POST("/logout", logout)
+
+	auth.GET("/autoAssign", RequirePermission(PermissionPlanAssign), autoAssign)
 
-	auth.GET("/autoAssign", autoAssign)
+	auth.GET("/events/pdf", RequirePermission(PermissionPdfExport), GetEventsPDF)
+	AllowApiKey(http.MethodGet, "/events/pdf", ScopePdfExport)
 
-	router.GET("/pdf/events", GetEventsPDF)
+	auth.GET("/apikeys", RequirePermission(PermissionApiKeysManage), getApiKeys)
+	auth.PUT("/apikeys", RequirePermission(PermissionApiKeysManage), createApiKey)
+	auth.DELETE("/apikeys/:apiKeyId", RequirePermission(PermissionApiKeysManage), revokeApiKey)
+
+	auth.GET("/role", getRoles)
+	auth.PATCH("/role/:roleId/permissions", RequirePermission(PermissionRolesManage), updateRolePermissions)
 
 	auth.GET("/events/:userId", getEventsForUser)
 	auth.GET("/events", getEventsByDateRange)
-	auth.PATCH("/events/:eventId/assign/add", AllowMinRole(2), addUserToEvent)
-	auth.PATCH("/events/:eventId/assign/remove", AllowMinRole(2), removeUserFromEvent)
-	auth.PUT("/event", AllowMinRole(2), putEvent)
+	AllowApiKey(http.MethodGet, "/events", ScopeEventsRead)
+	auth.PATCH("/events/:eventId/assign/add", RequirePermission(PermissionPlanAssign), addUserToEvent)
+	auth.PATCH("/events/:eventId/assign/remove", RequirePermission(PermissionPlanAssign), removeUserFromEvent)
+	auth.PUT("/event", RequirePermission(PermissionEventsWrite), putEvent)
+	auth.PATCH("/event/:eventId/status", RequirePermission(PermissionEventsWrite), updateEventStatus)
+	auth.POST("/events/publish", RequirePermission(PermissionPlanPublish), publishEvents)
+	auth.GET("/events/publish", RequirePermission(PermissionPlanPublish), getPlanPublications)
+	auth.POST("/events/copy", RequirePermission(PermissionEventsWrite), copyEvents)
+
+	auth.GET("/template", RequirePermission(PermissionEventsWrite), getTemplates)
+	auth.GET("/template/:templateId", RequirePermission(PermissionEventsWrite), getTemplate)
+	auth.PUT("/template", RequirePermission(PermissionEventsWrite), putTemplate)
+	auth.PATCH("/template/:templateId", RequirePermission(PermissionEventsWrite), updateTemplate)
+	auth.DELETE("/template/:templateId", RequirePermission(PermissionEventsWrite), deleteTemplate)
+	auth.PUT("/template/:templateId/event", RequirePermission(PermissionEventsWrite), putEventsFromTemplate)
+
+	auth.GET("/category", getCategories)
+	auth.PATCH("/category/:category", RequirePermission(PermissionEventsWrite), updateCategory)
 
 	auth.GET("/location", getLocations)
+	auth.GET("/location/:locationId", getLocation)
+	auth.PUT("/location", RequirePermission(PermissionEventsWrite), putLocation)
+	auth.PATCH("/location/:locationId", RequirePermission(PermissionEventsWrite), updateLocation)
+	auth.DELETE("/location/:locationId", RequirePermission(PermissionEventsWrite), deleteLocation)
 
 	auth.GET("/userHead", getAllUserHead)
 	auth.GET("/user", getAllUser)
 	auth.GET("/user/:userId", getUser)
-	auth.PATCH("/user/:userId", AllowSelfOrMinRole(2), updateUser)
-	auth.PATCH("/user/:userId/password", AllowSelfOrMinRole(2), updateUserPassword)
+	auth.PUT("/user", RequirePermission(PermissionUsersManage), putUser)
+	auth.POST("/user/import", RequirePermission(PermissionUsersManage), importUsers)
+	auth.GET("/user/export", RequirePermission(PermissionUsersManage), exportUsers)
+	auth.GET("/registrations", RequirePermission(PermissionUsersManage), getRegistrations)
+	auth.PATCH("/registrations/:userId", RequirePermission(PermissionUsersManage), decideRegistration)
+	auth.PATCH("/user/:userId/sessions/revoke", RequirePermission(PermissionUsersManage), RequireManageableUser(), revokeUserSessions)
+	auth.PATCH("/user/:userId/unlock", RequirePermission(PermissionUsersManage), RequireManageableUser(), unlockUser)
+	auth.PATCH("/user/:userId/deactivate", RequirePermission(PermissionUsersManage), RequireManageableUser(), deactivateUser)
+	auth.DELETE("/user/:userId", RequirePermission(PermissionUsersManage), RequireManageableUser(), deleteUser)
+	auth.GET("/user/:userId/export", AllowSelfGuardianOrPermission(PermissionUsersManage), exportUserData)
+	auth.PATCH("/user/:userId/erase", RequirePermission(PermissionUsersManage), RequireManageableUser(), eraseUser)
+	auth.PATCH("/user/:userId", AllowSelfOrPermission(PermissionUsersManage), RequireManageableUser(), updateUser)
+	auth.PATCH("/user/:userId/password", AllowSelfOrPermission(PermissionUsersManage), RequireManageableUser(), updateUserPassword)
 	auth.GET("/user/:userId/ban", getUserBanDates)
-	auth.PATCH("/user/:userId/ban", AllowSelfOrMinRole(2), updateUserBanDates)
+	auth.PATCH("/user/:userId/ban", AllowSelfGuardianOrPermission(PermissionUsersManage), updateUserBanDates)
+	auth.GET("/user/:userId/absence", getUserAbsences)
+	auth.PATCH("/user/:userId/absence", AllowSelfGuardianOrPermission(PermissionUsersManage), updateUserAbsences)
 	auth.GET("/user/:userId/weekday", getUserWeekdays)
-	auth.PATCH("/user/:userId/weekday", AllowSelfOrMinRole(2), updateUserWeekday)
-	auth.PATCH("/user/:userId/preferred", AllowSelfOrMinRole(2), updateUserPreferred)
+	auth.PATCH("/user/:userId/weekday", AllowSelfGuardianOrPermission(PermissionUsersManage), updateUserWeekday)
+	auth.PATCH("/user/:userId/preferred", AllowSelfGuardianOrPermission(PermissionUsersManage), updateUserPreferred)
 	auth.GET("/user/:userId/preferred", getUserPreferred)
-	auth.GET("/event/:eventId/assignment-options", AllowMinRole(2), getEventAssignmentOptions)
+	auth.GET("/user/:userId/preferred/requests", AllowSelfGuardianOrPermission(PermissionUsersManage), getUserPreferredRequests)
+	auth.PATCH("/user/:userId/preferred/respond", AllowSelfGuardianOrPermission(PermissionUsersManage), respondUserPreferred)
+	auth.GET("/user/:userId/children", AllowSelfOrPermission(PermissionUsersManage), getChildren)
+	auth.PATCH("/user/:userId/children", RequirePermission(PermissionUsersManage), updateChildren)
+	auth.GET("/children/events", getEventsForChildren)
+	auth.GET("/event/:eventId/attendance", RequirePermission(PermissionAttendance), getEventAttendance)
+	auth.PATCH("/event/:eventId/attendance", RequirePermission(PermissionAttendance), updateEventAttendance)
+	auth.GET("/attendance/stats", RequirePermission(PermissionAttendance), getAttendanceStats)
+	auth.GET("/user/:userId/attendance/stats", AllowSelfGuardianOrPermission(PermissionAttendance), getUserAttendanceStats)
+
+	auth.GET("/points/leaderboard", getPointsLeaderboard)
+	auth.POST("/points/archive", RequirePermission(PermissionPointsManage), archivePoints)
+	auth.GET("/user/:userId/points", AllowSelfGuardianOrPermission(PermissionPointsManage), getUserPoints)
+	auth.PUT("/user/:userId/points", RequirePermission(PermissionPointsManage), adjustUserPoints)
+
+	auth.GET("/swap", getSwapRequests)
+	auth.PUT("/swap", offerSwap)
+	auth.PATCH("/swap/:swapId/accept", acceptSwap)
+	auth.PATCH("/swap/:swapId/cancel", cancelSwap)
+	auth.PATCH("/swap/:swapId/approve", RequirePermission(PermissionPlanAssign), approveSwap)
+	auth.PATCH("/swap/:swapId/reject", RequirePermission(PermissionPlanAssign), rejectSwap)
+
+	auth.GET("/survey", getSurveys)
+	auth.PUT("/survey", RequirePermission(PermissionEventsWrite), putSurvey)
+	auth.DELETE("/survey/:surveyId", RequirePermission(PermissionEventsWrite), deleteSurvey)
+	auth.GET("/survey/:surveyId/missing", RequirePermission(PermissionEventsWrite), getSurveyMissing)
+	auth.PATCH("/user/:userId/survey/:surveyId", AllowSelfGuardianOrPermission(PermissionUsersManage), submitSurvey)
+	auth.GET("/ban-request", RequirePermission(PermissionUsersManage), getBanChangeRequests)
+	auth.PATCH("/ban-request/:requestId/approve", RequirePermission(PermissionUsersManage), approveBanChangeRequest)
+	auth.PATCH("/ban-request/:requestId/reject", RequirePermission(PermissionUsersManage), rejectBanChangeRequest)
+
+	auth.GET("/setting", RequirePermission(PermissionSettingsManage), getSettings)
+	auth.PATCH("/setting/:key", RequirePermission(PermissionSettingsManage), updateSetting)
+
+	auth.GET("/event/:eventId/assignment-options", RequirePermission(PermissionPlanAssign), getEventAssignmentOptions)
+
+	if err := router.Run(cfg.ListenAddr); err != nil {
+		log.Fatal(err)
+	}
+}
 
-	router.Run("localhost:8080")
+// trustProxies takes the client IP from X-Real-IP, but only on requests that
+// come from one of the proxies, e.g. nginx. All other requests keep the
+// address of the connection, so the throttles cannot be dodged with a forged
+// header.
+func trustProxies(router *gin.Engine, proxies []string) error {
+	router.RemoteIPHeaders = []string{"X-Real-IP"}
+	return router.SetTrustedProxies(proxies)
 }
 
 func login(c *gin.Context) {
 	var login Login
-	c.BindJSON(&login)
+	if err := c.ShouldBindJSON(&login); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
 	retJWT := DoLogin(login, c)
+	if c.IsAborted() {
+		return
+	}
 	c.IndentedJSON(http.StatusOK, retJWT)
 }
 
+func register(c *gin.Context) {
+	var payload Registration
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	if wait := RegistrationLockedFor(c.ClientIP()); wait > 0 {
+		seconds := int(math.Ceil(wait.Seconds()))
+		c.Header("Retry-After", strconv.Itoa(seconds))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Zu viele Anfragen, bitte warte etwas", "retryAfter": seconds})
+		return
+	}
+
+	id, err := Register(payload, c.ClientIP())
+	if err != nil {
+		switch err {
+		case ErrRegistrationClosed:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case ErrInvalidInviteCode:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case ErrUsernameTaken:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "pending", "id": id})
+}
+
+func forgotPassword(c *gin.Context) {
+	var payload ForgotPassword
+	if err := c.ShouldBindJSON(&payload); err != nil || payload.Login == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	if wait := PasswordResetLockedFor(payload.Login, c.ClientIP()); wait > 0 {
+		seconds := int(math.Ceil(wait.Seconds()))
+		c.Header("Retry-After", strconv.Itoa(seconds))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Zu viele Anfragen, bitte warte etwas", "retryAfter": seconds})
+		return
+	}
+
+	RequestPasswordReset(payload.Login, c.ClientIP())
+	// same answer for known and unknown users and for failed mails
+	c.JSON(http.StatusOK, gin.H{"status": "requested"})
+}
+
+func resetPassword(c *gin.Context) {
+	var payload ResetPassword
+	if err := c.ShouldBindJSON(&payload); err != nil || payload.Token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	if err := ResetPasswordWithToken(payload.Token, payload.Password); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "password changed"})
+}
+
 func autoAssign(c *gin.Context) {
 
 	eventId := c.Query("eventId")
@@ -70,14 +259,142 @@ func autoAssign(c *gin.Context) {
 	c.IndentedJSON(http.StatusOK, "ok")
 }
 
+func refreshToken(c *gin.Context) {
+	var payload RefreshRequest
+	if err := c.ShouldBindJSON(&payload); err != nil || payload.RefreshToken == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	token, err := RefreshSession(payload.RefreshToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	c.IndentedJSON(http.StatusOK, token)
+}
+
+func getOIDCConfig(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"enabled": OIDCEnabled()})
+}
+
+func oidcLogin(c *gin.Context) {
+	authURL, state, err := StartOIDCLogin(c.Request.Context())
+	if err != nil {
+		if err == ErrOIDCDisabled {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Anmeldedienst nicht erreichbar", "details": err.Error()})
+		return
+	}
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(OIDCStateCookie, state, 600, "/oidc", "", c.Request.TLS != nil, true)
+	c.Redirect(http.StatusFound, authURL)
+}
+
+func oidcCallback(c *gin.Context) {
+	state, _ := c.Cookie(OIDCStateCookie)
+	c.SetCookie(OIDCStateCookie, "", -1, "/oidc", "", c.Request.TLS != nil, true)
+
+	var token AccessToken
+	err := ErrOIDCState
+	if providerError := c.Query("error"); providerError != "" {
+		err = errors.New("provider returned " + providerError)
+	} else if identity, identityErr := FinishOIDCLogin(c.Request.Context(), c.Query("code"), c.Query("state"), state); identityErr != nil {
+		err = identityErr
+	} else {
+		token, err = OIDCSession(identity, c)
+	}
+	if err != nil && err != ErrOIDCState && err != ErrOIDCNoAccount && err != ErrOIDCDisabled && err != ErrAccountDeactivated {
+		log.Printf("oidc login failed: %v", err)
+		err = errors.New("Die Anmeldung über das Bistumskonto ist fehlgeschlagen")
+	}
+
+	if redirect := OIDCRedirect(token, err); redirect != "" {
+		c.Redirect(http.StatusFound, redirect)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	c.IndentedJSON(http.StatusOK, token)
+}
+
+func logout(c *gin.Context) {
+	RevokeSession(GetTokenSessionId(c))
+	c.JSON(http.StatusOK, gin.H{"status": "logged out"})
+}
+
+func revokeUserSessions(c *gin.Context) {
+	revoked := RevokeUserSessions(c.Param("userId"))
+	c.JSON(http.StatusOK, gin.H{"status": "revoked", "sessions": revoked})
+}
+
+func getApiKeys(c *gin.Context) {
+	c.IndentedJSON(http.StatusOK, gin.H{"keys": GetApiKeys(), "scopes": AllApiKeyScopes})
+}
+
+func createApiKey(c *gin.Context) {
+	var payload ApiKeyCreate
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	id, key, err := CreateApiKey(payload, GetTokenUserId(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "created", "id": id, "key": key})
+}
+
+func revokeApiKey(c *gin.Context) {
+	if !RevokeApiKey(c.Param("apiKeyId")) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Schlüssel nicht gefunden"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "revoked"})
+}
+
 func checkToken(c *gin.Context) {
-	tokenRes := CheckToken(c)
+	principal, _ := GetPrincipal(c)
+	tokenRes := CheckToken(principal)
 	c.IndentedJSON(http.StatusOK, tokenRes)
 }
 
+func getRoles(c *gin.Context) {
+	roles := GetRoles()
+	c.IndentedJSON(http.StatusOK, roles)
+}
+
+func updateRolePermissions(c *gin.Context) {
+	roleId := c.Param("roleId")
+
+	var update RolePermissionUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		c.JSON(400, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	// keep at least the own role able to manage roles, otherwise nobody can undo it
+	if roleId == strconv.Itoa(GetTokenRoleId(c)) && !slices.Contains(update.Permissions, PermissionRolesManage) {
+		c.JSON(400, gin.H{"error": "Die eigene Rolle muss Rollen verwalten dürfen"})
+		return
+	}
+
+	if err := SetRolePermissions(roleId, update.Permissions); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"status": "ok"})
+}
+
 func getEventsForUser(c *gin.Context) {
 	userId := c.Param("userId")
-	events := GetEventsForUser(userId)
+	events := GetEventsForUser(userId, !TokenHasPermission(c, PermissionPlanAssign))
 	c.IndentedJSON(http.StatusOK, events)
 }
 
@@ -85,10 +402,57 @@ func getEventsByDateRange(c *gin.Context) {
 	from := c.Query("from")
 	to := c.Query("to")
 
-	events := GetEventsByDateRange(from, to)
+	events := GetEventsByDateRange(from, to, !TokenHasPermission(c, PermissionPlanAssign))
 	c.IndentedJSON(http.StatusOK, events)
 }
 
+func updateEventStatus(c *gin.Context) {
+	eventId := c.Param("eventId")
+
+	var update EventStatusUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		c.JSON(400, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	if !SetEventStatus(eventId, update.Status) {
+		c.JSON(400, gin.H{"error": "unknown status"})
+		return
+	}
+	c.JSON(200, gin.H{"status": "ok"})
+}
+
+func publishEvents(c *gin.Context) {
+	var payload PublishRange
+	if err := c.ShouldBindJSON(&payload); err != nil || payload.From == "" || payload.To == "" {
+		c.JSON(400, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	count := PublishEventsInRange(payload.From, payload.To, GetTokenUserId(c))
+	c.JSON(200, gin.H{"status": "published", "events": count})
+}
+
+func getPlanPublications(c *gin.Context) {
+	publications := GetPlanPublications()
+	c.IndentedJSON(http.StatusOK, publications)
+}
+
+func copyEvents(c *gin.Context) {
+	var payload EventCopyRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(400, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	report, err := CopyEvents(payload)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Termine konnten nicht kopiert werden", "details": err.Error()})
+		return
+	}
+	c.IndentedJSON(http.StatusOK, report)
+}
+
 func addUserToEvent(c *gin.Context) {
 	eventId := c.Param("eventId")
 
@@ -101,7 +465,14 @@ func addUserToEvent(c *gin.Context) {
 		return
 	}
 
-	AddUserToEvent(eventId, payload.UserId)
+	if err := AddUserToEvent(eventId, payload.UserId); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(404, gin.H{"error": "event not found"})
+			return
+		}
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
 
 	c.JSON(200, gin.H{"status": "added"})
 }
@@ -123,11 +494,93 @@ func removeUserFromEvent(c *gin.Context) {
 	c.JSON(200, gin.H{"status": "removed"})
 }
 
+func getCategories(c *gin.Context) {
+	categories := GetCategories()
+	c.IndentedJSON(http.StatusOK, categories)
+}
+
+func updateCategory(c *gin.Context) {
+	category := c.Param("category")
+	var update EventCategoryUpdate
+	if err := c.BindJSON(&update); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+		return
+	}
+
+	if err := UpdateCategory(category, update); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Kategorie nicht gefunden"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Kategorie konnte nicht gespeichert werden", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "updated"})
+}
+
 func getLocations(c *gin.Context) {
-	locations := GetLocations()
+	includeInactive := c.Query("includeInactive") == "true"
+	locations := GetLocations(includeInactive)
 	c.IndentedJSON(200, locations)
 }
 
+func getLocation(c *gin.Context) {
+	locationId := c.Param("locationId")
+	location, err := GetLocation(locationId)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ort nicht gefunden"})
+		return
+	}
+	c.IndentedJSON(http.StatusOK, location)
+}
+
+func putLocation(c *gin.Context) {
+	location := Location{Active: true}
+	if err := c.BindJSON(&location); err != nil {
+		c.JSON(400, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	id, err := CreateLocation(location)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ort konnte nicht angelegt werden", "details": err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"status": "created",
+		"id":     id,
+	})
+}
+
+func updateLocation(c *gin.Context) {
+	locationId := c.Param("locationId")
+	var update LocationUpdate
+	if err := c.BindJSON(&update); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+		return
+	}
+	if err := UpdateLocation(locationId, update); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Ort nicht gefunden"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ort konnte nicht gespeichert werden", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "updated"})
+}
+
+func deleteLocation(c *gin.Context) {
+	locationId := c.Param("locationId")
+	if !DeleteLocation(locationId) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Ort wird noch verwendet und kann nur deaktiviert werden"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+}
+
 func putEvent(c *gin.Context) {
 	var ev Event
 	if err := c.BindJSON(&ev); err != nil {
@@ -135,7 +588,16 @@ func putEvent(c *gin.Context) {
 		return
 	}
 
-	id := CreateEvent(ev)
+	if !IsLocationActive(ev.LocationID) {
+		c.JSON(400, gin.H{"error": "Ort ist nicht mehr aktiv"})
+		return
+	}
+
+	id, err := CreateEvent(ev)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Termin konnte nicht angelegt werden", "details": err.Error()})
+		return
+	}
 
 	c.JSON(200, gin.H{
 		"status": "created",
@@ -143,6 +605,86 @@ func putEvent(c *gin.Context) {
 	})
 }
 
+func getTemplates(c *gin.Context) {
+	templates := GetTemplates()
+	c.IndentedJSON(http.StatusOK, templates)
+}
+
+func getTemplate(c *gin.Context) {
+	templateId := c.Param("templateId")
+	template, err := GetTemplate(templateId)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Vorlage nicht gefunden"})
+		return
+	}
+	c.IndentedJSON(http.StatusOK, template)
+}
+
+func putTemplate(c *gin.Context) {
+	var template EventTemplate
+	if err := c.BindJSON(&template); err != nil {
+		c.JSON(400, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	id, err := CreateTemplate(template)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Vorlage konnte nicht angelegt werden", "details": err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"status": "created",
+		"id":     id,
+	})
+}
+
+func updateTemplate(c *gin.Context) {
+	templateId := c.Param("templateId")
+	var update EventTemplateUpdate
+	if err := c.BindJSON(&update); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+		return
+	}
+
+	if err := UpdateTemplate(templateId, update); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Vorlage nicht gefunden"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Vorlage konnte nicht gespeichert werden", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "updated"})
+}
+
+func deleteTemplate(c *gin.Context) {
+	templateId := c.Param("templateId")
+	DeleteTemplate(templateId)
+	c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+}
+
+func putEventsFromTemplate(c *gin.Context) {
+	templateId := c.Param("templateId")
+
+	var payload EventFromTemplate
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(400, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	ids, err := CreateEventsFromTemplate(templateId, payload)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Termine konnten nicht aus der Vorlage erstellt werden", "details": err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"status": "created",
+		"ids":    ids,
+	})
+}
+
 func getAllUserHead(c *gin.Context) {
 	users := GetAllUserHead()
 	c.IndentedJSON(http.StatusOK, users)
@@ -159,14 +701,211 @@ func getUser(c *gin.Context) {
 	c.IndentedJSON(http.StatusOK, user)
 }
 
+func putUser(c *gin.Context) {
+	var payload NewUser
+	if err := c.BindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+		return
+	}
+
+	principal, _ := GetPrincipal(c)
+	id, initialPassword, err := CreateUser(payload, principal.Permissions)
+	if err == ErrUsernameTaken {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err == ErrRoleNotAssignable {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := gin.H{
+		"status": "created",
+		"id":     id,
+	}
+	if initialPassword != "" {
+		response["initialPassword"] = initialPassword
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func importUsers(c *gin.Context) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Keine Datei hochgeladen"})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datei konnte nicht gelesen werden"})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datei konnte nicht gelesen werden"})
+		return
+	}
+
+	sheet, err := ReadUserSheet(fileHeader.Filename, data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datei konnte nicht gelesen werden", "details": err.Error()})
+		return
+	}
+
+	principal, _ := GetPrincipal(c)
+	var result UserImportResult
+	if c.Query("commit") == "true" {
+		result, err = ImportUsers(sheet, principal.Permissions)
+	} else {
+		result, err = PreviewUserImport(sheet, principal.Permissions)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datei konnte nicht importiert werden", "details": err.Error()})
+		return
+	}
+	c.IndentedJSON(http.StatusOK, result)
+}
+
+func exportUsers(c *gin.Context) {
+	format := c.DefaultQuery("format", "csv")
+
+	data, err := ExportUsers(format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Export konnte nicht erzeugt werden", "details": err.Error()})
+		return
+	}
+
+	contentType := "text/csv; charset=utf-8"
+	if format == "xlsx" {
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	c.Header("Content-Disposition", "attachment; filename=Ministranten."+format)
+	c.Data(http.StatusOK, contentType, data)
+}
+
+func getRegistrations(c *gin.Context) {
+	c.IndentedJSON(http.StatusOK, GetPendingRegistrations())
+}
+
+func decideRegistration(c *gin.Context) {
+	userId := c.Param("userId")
+	var payload RegistrationDecision
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	if err := DecideRegistration(userId, payload.Approve); err != nil {
+		if err == ErrRegistrationMissing {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Registrierung konnte nicht bearbeitet werden", "details": err.Error()})
+		return
+	}
+	status := "rejected"
+	if payload.Approve {
+		status = "approved"
+	}
+	c.JSON(http.StatusOK, gin.H{"status": status})
+}
+
+func unlockUser(c *gin.Context) {
+	if !UnlockUser(c.Param("userId")) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Person nicht gefunden"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "unlocked"})
+}
+
+func deactivateUser(c *gin.Context) {
+	userId := c.Param("userId")
+	removed := DeactivateUser(userId)
+	c.JSON(http.StatusOK, gin.H{"status": "deactivated", "removedAssignments": removed})
+}
+
+func deleteUser(c *gin.Context) {
+	userId := c.Param("userId")
+	if userId == strconv.Itoa(GetTokenUserId(c)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Das eigene Konto kann nicht gelöscht werden"})
+		return
+	}
+
+	if err := DeleteUser(userId); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Person nicht gefunden"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Person konnte nicht gelöscht werden", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+}
+
+func exportUserData(c *gin.Context) {
+	userId := c.Param("userId")
+	export, err := ExportUserData(userId)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Person nicht gefunden"})
+		return
+	}
+
+	filename := UserExportFilename(userId)
+	if c.Query("format") == "zip" {
+		data, err := ExportUserDataZip(export)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Export fehlgeschlagen", "details": err.Error()})
+			return
+		}
+		c.Header("Content-Disposition", "attachment; filename="+filename+".zip")
+		c.Data(http.StatusOK, "application/zip", data)
+		return
+	}
+	c.Header("Content-Disposition", "attachment; filename="+filename+".json")
+	c.IndentedJSON(http.StatusOK, export)
+}
+
+func eraseUser(c *gin.Context) {
+	userId := c.Param("userId")
+	if userId == strconv.Itoa(GetTokenUserId(c)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Das eigene Konto kann nicht gelöscht werden"})
+		return
+	}
+
+	if err := EraseUser(userId); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Person nicht gefunden oder bereits gelöscht"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Daten konnten nicht gelöscht werden", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "erased"})
+}
+
 func updateUser(c *gin.Context) {
 	userId := c.Param("userId")
-	var payload User
+	var payload UserUpdate
 	if err := c.BindJSON(&payload); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
 		return
 	}
-	UpdateUser(userId, payload)
+	if err := UpdateUser(userId, payload, TokenHasPermission(c, PermissionUsersManage)); err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Person nicht gefunden"})
+		case ErrUserFieldForbidden:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Person konnte nicht gespeichert werden", "details": err.Error()})
+		}
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{"status": "updated"})
 }
@@ -183,7 +922,10 @@ func updateUserPassword(c *gin.Context) {
 		return
 	}
 
-	UpdatePassword(userId, payload.Password)
+	if err := UpdatePassword(userId, payload.Password); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{"status": "password changed"})
 }
 
@@ -202,14 +944,41 @@ func updateUserBanDates(c *gin.Context) {
 		return
 	}
 
-	if update.Add {
-		AddBlockDate(userId, update.Date)
-	} else {
-		RemoveBlockDate(userId, update.Date)
+	if ChangeBanDate(userId, update, TokenHasPermission(c, PermissionUsersManage)) {
+		c.JSON(http.StatusAccepted, gin.H{"status": "pending approval"})
+		return
 	}
 	c.JSON(200, gin.H{"status": "ok"})
 }
 
+func getUserAbsences(c *gin.Context) {
+	userId := c.Param("userId")
+	absences := GetAbsences(userId)
+	c.IndentedJSON(http.StatusOK, absences)
+}
+
+func updateUserAbsences(c *gin.Context) {
+	userId := c.Param("userId")
+
+	var update AbsenceUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		c.JSON(400, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	if err := ValidateAbsences(update); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	ids, pending, err := UpdateAbsences(userId, update, TokenHasPermission(c, PermissionUsersManage))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Abwesenheiten konnten nicht gespeichert werden", "details": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"status": "ok", "ids": ids, "pending": pending})
+}
+
 func getUserWeekdays(c *gin.Context) {
 	userId := c.Param("userId")
 	weekdays := GetUserWeekdays(userId)
@@ -243,10 +1012,15 @@ func updateUserPreferred(c *gin.Context) {
 		return
 	}
 
+	var err error
 	if update.Add {
-		AddPreferredUser(userId, update.OtherUserId)
+		err = AddPreferredUser(userId, update.OtherUserId)
 	} else {
-		RemovePreferredUser(userId, update.OtherUserId)
+		err = RemovePreferredUser(userId, update.OtherUserId)
+	}
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
 	}
 
 	c.JSON(200, gin.H{"status": "ok"})
@@ -260,6 +1034,314 @@ func getUserPreferred(c *gin.Context) {
 	c.JSON(200, data)
 }
 
+func getUserPreferredRequests(c *gin.Context) {
+	userId := c.Param("userId")
+	overview := GetPreferredOverview(userId)
+	c.IndentedJSON(http.StatusOK, overview)
+}
+
+func respondUserPreferred(c *gin.Context) {
+	userId := c.Param("userId")
+
+	var response PreferredResponse
+	if err := c.ShouldBindJSON(&response); err != nil {
+		c.JSON(400, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	if !RespondPreferredRequest(userId, response.OtherUserId, response.Accept) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Keine offene Anfrage gefunden"})
+		return
+	}
+	c.JSON(200, gin.H{"status": "ok"})
+}
+
+func getChildren(c *gin.Context) {
+	userId := c.Param("userId")
+	children := GetChildren(userId)
+	c.IndentedJSON(http.StatusOK, children)
+}
+
+func updateChildren(c *gin.Context) {
+	userId := c.Param("userId")
+
+	var update GuardianChildUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		c.JSON(400, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	if update.Add {
+		AddChild(userId, update.ChildId)
+	} else {
+		RemoveChild(userId, update.ChildId)
+	}
+
+	c.JSON(200, gin.H{"status": "ok"})
+}
+
+func getEventsForChildren(c *gin.Context) {
+	events := GetEventsForChildren(GetTokenUserId(c))
+	c.IndentedJSON(http.StatusOK, events)
+}
+
+func getEventAttendance(c *gin.Context) {
+	eventId := c.Param("eventId")
+	attendance := GetEventAttendance(eventId)
+	c.IndentedJSON(http.StatusOK, attendance)
+}
+
+func updateEventAttendance(c *gin.Context) {
+	eventId := c.Param("eventId")
+
+	var update AttendanceUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		c.JSON(400, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	if err := SetAttendance(eventId, update, GetTokenUserId(c)); err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			c.JSON(404, gin.H{"error": "event not found"})
+		case ErrAttendanceNotAssigned:
+			c.JSON(404, gin.H{"error": err.Error()})
+		default:
+			c.JSON(400, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(200, gin.H{"status": "ok"})
+}
+
+func getAttendanceStats(c *gin.Context) {
+	from := c.DefaultQuery("from", "1970-01-01")
+	to := c.DefaultQuery("to", "9999-12-31")
+	stats := GetAttendanceStats(from, to, "")
+	c.IndentedJSON(http.StatusOK, stats)
+}
+
+func getUserAttendanceStats(c *gin.Context) {
+	userId := c.Param("userId")
+	from := c.DefaultQuery("from", "1970-01-01")
+	to := c.DefaultQuery("to", "9999-12-31")
+
+	stats := AttendanceStats{}
+	if list := GetAttendanceStats(from, to, userId); len(list) > 0 {
+		stats = list[0]
+	}
+	c.IndentedJSON(http.StatusOK, stats)
+}
+
+func getPointsLeaderboard(c *gin.Context) {
+	year, _ := strconv.Atoi(c.Query("year"))
+	leaderboard := GetLeaderboard(year)
+	c.IndentedJSON(http.StatusOK, leaderboard)
+}
+
+func archivePoints(c *gin.Context) {
+	var payload PointsArchive
+	if err := c.ShouldBindJSON(&payload); err != nil || payload.Year == 0 {
+		c.JSON(400, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	count := ArchivePoints(payload.Year)
+	c.JSON(200, gin.H{"status": "archived", "entries": count})
+}
+
+func getUserPoints(c *gin.Context) {
+	userId := c.Param("userId")
+	points := GetUserPoints(userId)
+	c.IndentedJSON(http.StatusOK, points)
+}
+
+func adjustUserPoints(c *gin.Context) {
+	userId := c.Param("userId")
+
+	var adjustment PointsAdjustment
+	if err := c.ShouldBindJSON(&adjustment); err != nil {
+		c.JSON(400, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	if err := AdjustPoints(userId, adjustment, GetTokenUserId(c)); err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			c.JSON(404, gin.H{"error": "Person nicht gefunden"})
+		case ErrPointsZero, ErrPointsReasonMissing:
+			c.JSON(400, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Korrektur konnte nicht gespeichert werden", "details": err.Error()})
+		}
+		return
+	}
+	c.JSON(200, gin.H{"status": "ok"})
+}
+
+func getSwapRequests(c *gin.Context) {
+	swaps := GetSwapRequests(GetTokenUserId(c), TokenHasPermission(c, PermissionPlanAssign))
+	c.IndentedJSON(http.StatusOK, swaps)
+}
+
+func offerSwap(c *gin.Context) {
+	var payload SwapOffer
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(400, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	id, err := OfferSwap(GetTokenUserId(c), payload.EventId)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"status": "created", "id": id})
+}
+
+func acceptSwap(c *gin.Context) {
+	swapId := c.Param("swapId")
+	status, err := AcceptSwap(swapId, GetTokenUserId(c))
+	if err != nil {
+		respondSwapError(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"status": status})
+}
+
+func cancelSwap(c *gin.Context) {
+	swapId := c.Param("swapId")
+	if err := CancelSwap(swapId, GetTokenUserId(c)); err != nil {
+		respondSwapError(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"status": "cancelled"})
+}
+
+func approveSwap(c *gin.Context) {
+	swapId := c.Param("swapId")
+	if err := DecideSwap(swapId, true, GetTokenUserId(c)); err != nil {
+		respondSwapError(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"status": "completed"})
+}
+
+func rejectSwap(c *gin.Context) {
+	swapId := c.Param("swapId")
+	if err := DecideSwap(swapId, false, GetTokenUserId(c)); err != nil {
+		respondSwapError(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"status": "rejected"})
+}
+
+func respondSwapError(c *gin.Context, err error) {
+	switch err {
+	case ErrSwapNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case ErrSwapNotAllowed, ErrSwapEventClosed:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case ErrSwapNotServing:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
+
+func getSurveys(c *gin.Context) {
+	surveys := GetSurveys()
+	c.IndentedJSON(http.StatusOK, surveys)
+}
+
+func putSurvey(c *gin.Context) {
+	var survey AvailabilitySurvey
+	if err := c.ShouldBindJSON(&survey); err != nil {
+		c.JSON(400, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	id, err := CreateSurvey(survey, GetTokenUserId(c))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"status": "created", "id": id})
+}
+
+func deleteSurvey(c *gin.Context) {
+	surveyId := c.Param("surveyId")
+	DeleteSurvey(surveyId)
+	c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+}
+
+func getSurveyMissing(c *gin.Context) {
+	surveyId := c.Param("surveyId")
+	users := GetMissingResponses(surveyId)
+	c.IndentedJSON(http.StatusOK, users)
+}
+
+func submitSurvey(c *gin.Context) {
+	userId := c.Param("userId")
+	surveyId := c.Param("surveyId")
+	if err := SubmitSurvey(surveyId, userId); err != nil {
+		switch err {
+		case ErrSurveyNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case ErrSurveyClosed:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Abfrage konnte nicht abgegeben werden", "details": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "submitted"})
+}
+
+func getBanChangeRequests(c *gin.Context) {
+	requests := GetBanChangeRequests(c.DefaultQuery("status", "pending"))
+	c.IndentedJSON(http.StatusOK, requests)
+}
+
+func approveBanChangeRequest(c *gin.Context) {
+	requestId := c.Param("requestId")
+	if err := DecideBanChangeRequest(requestId, true, GetTokenUserId(c)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "approved"})
+}
+
+func rejectBanChangeRequest(c *gin.Context) {
+	requestId := c.Param("requestId")
+	if err := DecideBanChangeRequest(requestId, false, GetTokenUserId(c)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "rejected"})
+}
+
+func getSettings(c *gin.Context) {
+	settings := GetSettings()
+	c.IndentedJSON(http.StatusOK, settings)
+}
+
+func updateSetting(c *gin.Context) {
+	key := c.Param("key")
+
+	var payload Setting
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(400, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	if !UpdateSetting(key, payload.Value) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Einstellung nicht gefunden"})
+		return
+	}
+	c.JSON(200, gin.H{"status": "ok"})
+}
+
 func GetEventsPDF(c *gin.Context) {
 	fromStr := c.Query("from")
 	toStr := c.Query("to")
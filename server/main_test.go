@@ -0,0 +1,47 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestClientIPIgnoresForgedHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	if err := trustProxies(router, []string{"172.28.0.10"}); err != nil {
+		t.Fatalf("trust proxies: %v", err)
+	}
+	router.GET("/ip", func(c *gin.Context) { c.String(http.StatusOK, c.ClientIP()) })
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		want       string
+	}{
+		{"direct", "203.0.113.7:5000", nil, "203.0.113.7"},
+		{"forged headers without proxy", "203.0.113.7:5000",
+			map[string]string{"X-Forwarded-For": "198.51.100.1", "X-Real-IP": "198.51.100.2"}, "203.0.113.7"},
+		{"nginx", "172.28.0.10:5000", map[string]string{"X-Real-IP": "203.0.113.7"}, "203.0.113.7"},
+		{"forwarded for through nginx", "172.28.0.10:5000",
+			map[string]string{"X-Forwarded-For": "198.51.100.1", "X-Real-IP": "203.0.113.7"}, "203.0.113.7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/ip", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if got := w.Body.String(); got != tt.want {
+				t.Errorf("client IP = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,48 +1,117 @@
 package middleware
 
 import (
-	"fmt"
 	. "minisAPI/controller"
 	. "minisAPI/models"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
+const principalKey = "principal"
+
+// apiKeyRoutes maps "METHOD /path" to the scope an API key needs for it.
+var apiKeyRoutes = map[string]string{}
+
+// AllowApiKey opens a route for API keys with the scope. AuthUser rejects API
+// keys on all routes that were not allowed this way.
+func AllowApiKey(method string, path string, scope string) {
+	apiKeyRoutes[method+" "+path] = scope
+}
+
+// AuthUser verifies the access token or API key and stores the resolved
+// Principal in the context. Handlers read it with GetPrincipal instead of the
+// raw claims.
 func AuthUser() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		isAllowed, claims := ExtractToken(c)
-
-		if claims == nil {
+		token := BearerToken(c)
+		if token == "" {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, ResponseText{Reason: "no token provided"})
 			return
 		}
 
-		if !isAllowed {
+		if strings.HasPrefix(token, ApiKeyPrefix) {
+			authApiKey(c, token)
+			return
+		}
+
+		claims, err := ParseAccessToken(token)
+		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
 			return
 		}
 
-		c.Set("claims", claims)
+		principal, err := ResolvePrincipal(claims)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "session revoked"})
+			return
+		}
+
+		c.Set(principalKey, principal)
 		c.Next()
 	}
 }
 
-func AllowSelfOrMinRole(minRole int) gin.HandlerFunc {
+func authApiKey(c *gin.Context, key string) {
+	principal, err := ResolveApiKey(key)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	scope, allowed := apiKeyRoutes[c.Request.Method+" "+c.FullPath()]
+	if !allowed || !principal.HasPermission(scope) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+		return
+	}
+
+	c.Set(principalKey, principal)
+	c.Next()
+}
+
+// GetPrincipal returns the caller set by AuthUser.
+func GetPrincipal(c *gin.Context) (Principal, bool) {
+	value, exists := c.Get(principalKey)
+	if !exists {
+		return Principal{}, false
+	}
+	principal, ok := value.(Principal)
+	return principal, ok
+}
+
+// AllowSelfOrPermission lets users change their own data and everyone whose
+// role grants the permission change the data of others.
+func AllowSelfOrPermission(permission string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		claimsVal, exists := c.Get("claims")
-		if !exists {
+		principal, ok := GetPrincipal(c)
+		if !ok {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 			return
 		}
 
-		claims := claimsVal.(jwt.MapClaims)
-		role := int(claims["roleId"].(float64))
-		tokenUserId := fmt.Sprintf("%.0f", claims["userId"].(float64))
-		paramUserId := c.Param("userId")
+		if strconv.Itoa(principal.UserId) != c.Param("userId") && !principal.HasPermission(permission) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+			return
+		}
 
-		if tokenUserId != paramUserId && role < minRole {
+		c.Next()
+	}
+}
+
+// AllowSelfGuardianOrPermission works like AllowSelfOrPermission and also lets
+// guardians change the data of their linked children.
+func AllowSelfGuardianOrPermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := GetPrincipal(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		paramUserId := c.Param("userId")
+		if strconv.Itoa(principal.UserId) != paramUserId && !IsGuardianOf(principal.UserId, paramUserId) && !principal.HasPermission(permission) {
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
 			return
 		}
@@ -51,18 +120,38 @@ func AllowSelfOrMinRole(minRole int) gin.HandlerFunc {
 	}
 }
 
-func AllowMinRole(minRole int) gin.HandlerFunc {
+// RequireManageableUser refuses changes to users whose role grants
+// permissions the caller lacks, so users.manage alone does not reach accounts
+// with more rights. The own account is always fine.
+func RequireManageableUser() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		claimsVal, exists := c.Get("claims")
-		if !exists {
+		principal, ok := GetPrincipal(c)
+		if !ok {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 			return
 		}
 
-		claims := claimsVal.(jwt.MapClaims)
-		role := int(claims["roleId"].(float64))
+		paramUserId := c.Param("userId")
+		if strconv.Itoa(principal.UserId) != paramUserId {
+			if err := CheckManageableUser(paramUserId, principal.Permissions); err != nil {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := GetPrincipal(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
 
-		if role < minRole {
+		if !principal.HasPermission(permission) {
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
 			return
 		}
@@ -70,3 +159,23 @@ func AllowMinRole(minRole int) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+func TokenHasPermission(c *gin.Context, permission string) bool {
+	principal, _ := GetPrincipal(c)
+	return principal.HasPermission(permission)
+}
+
+func GetTokenUserId(c *gin.Context) int {
+	principal, _ := GetPrincipal(c)
+	return principal.UserId
+}
+
+func GetTokenRoleId(c *gin.Context) int {
+	principal, _ := GetPrincipal(c)
+	return principal.RoleId
+}
+
+func GetTokenSessionId(c *gin.Context) int {
+	principal, _ := GetPrincipal(c)
+	return principal.SessionId
+}
@@ -0,0 +1,34 @@
+package models
+
+// ApiKeyPrefix starts every API key, so AuthUser can tell keys from JWTs.
+const ApiKeyPrefix = "mak_"
+
+// Scopes an API key can be granted. Scopes that match a permission unlock the
+// routes guarded by that permission.
+const (
+	ScopeEventsRead = "events.read"
+	ScopePdfExport  = PermissionPdfExport
+)
+
+var AllApiKeyScopes = []string{
+	ScopeEventsRead,
+	ScopePdfExport,
+}
+
+type ApiKey struct {
+	Id         int      `json:"id"`
+	Name       string   `json:"name"`
+	Prefix     string   `json:"prefix"`
+	Scopes     []string `json:"scopes"`
+	CreatedBy  string   `json:"createdBy"`
+	CreatedAt  string   `json:"createdAt"`
+	ExpiresAt  *string  `json:"expiresAt"`
+	LastUsedAt *string  `json:"lastUsedAt"`
+	RevokedAt  *string  `json:"revokedAt"`
+}
+
+type ApiKeyCreate struct {
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`
+	ExpiresAt string   `json:"expiresAt"`
+}
@@ -0,0 +1,34 @@
+package models
+
+const (
+	AttendancePresent = "present"
+	AttendanceExcused = "excused"
+	AttendanceNoShow  = "no_show"
+)
+
+type EventAttendance struct {
+	UserId     int    `json:"userId"`
+	Firstname  string `json:"firstname"`
+	Lastname   string `json:"lastname"`
+	Attendance string `json:"attendance"`
+	Note       string `json:"note"`
+}
+
+type AttendanceUpdate struct {
+	UserId     int    `json:"userId"`
+	Attendance string `json:"attendance"`
+	Note       string `json:"note"`
+}
+
+type AttendanceStats struct {
+	UserId           int     `json:"userId"`
+	Firstname        string  `json:"firstname"`
+	Lastname         string  `json:"lastname"`
+	Assigned         int     `json:"assigned"`
+	Present          int     `json:"present"`
+	Excused          int     `json:"excused"`
+	NoShow           int     `json:"noShow"`
+	Unrecorded       int     `json:"unrecorded"`
+	WeightedServices float64 `json:"weightedServices"`
+	NoShowRate       float64 `json:"noShowRate"`
+}
@@ -1,5 +1,7 @@
 package models
 
+import "slices"
+
 type Login struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
@@ -10,9 +12,31 @@ type ResponseText struct {
 }
 
 type AccessToken struct {
-	AccessToken string `json:"accessToken"`
+	AccessToken  string `json:"accessToken"`
+	RefreshToken string `json:"refreshToken,omitempty"`
+	ExpiresIn    int    `json:"expiresIn,omitempty"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
 }
 
 type AuthHeader struct {
 	IDToken string `header:"Authorization"`
 }
+
+// Principal is the authenticated caller of a request, resolved by the
+// AuthUser middleware. For an API key UserId is 0 and Permissions holds the
+// scopes of the key.
+type Principal struct {
+	UserId      int
+	RoleId      int
+	Role        string
+	Permissions []string
+	SessionId   int
+	ApiKeyId    int
+}
+
+func (p Principal) HasPermission(permission string) bool {
+	return slices.Contains(p.Permissions, permission)
+}
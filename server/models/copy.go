@@ -0,0 +1,25 @@
+package models
+
+type EventCopyRequest struct {
+	From            string `json:"from"`
+	To              string `json:"to"`
+	TargetFrom      string `json:"targetFrom"`
+	CopyAssignments bool   `json:"copyAssignments"`
+}
+
+type DroppedAssignment struct {
+	SourceEventId int    `json:"sourceEventId"`
+	TargetEventId int    `json:"targetEventId"`
+	Date          string `json:"date"`
+	UserId        int    `json:"userId"`
+	Firstname     string `json:"firstname"`
+	Lastname      string `json:"lastname"`
+	Status        string `json:"status"`
+	Reason        string `json:"reason"`
+}
+
+type EventCopyReport struct {
+	CreatedEventIds    []int               `json:"createdEventIds"`
+	CopiedAssignments  int                 `json:"copiedAssignments"`
+	DroppedAssignments []DroppedAssignment `json:"droppedAssignments"`
+}
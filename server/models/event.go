@@ -9,17 +9,62 @@ type Event struct {
 	Location      string `json:"location"`
 	MinimalUser   int    `json:"minimalUser"`
 	IgnoreWeekday bool   `json:"ignoreWeekday"`
+	TemplateId    *int   `json:"templateId"`
+	Status        string `json:"status"`
+	Category      string `json:"category"`
 }
 
 type PlannedEvent struct {
-	Id              int    `json:"id"`
-	Name            string `json:"name"`
-	DateBegin       string `json:"dateBegin"`
-	TimeBegin       string `json:"timeBegin"`
-	LocationID      int    `json:"locationId"`
-	Location        string `json:"location"`
-	MinimalUser     int    `json:"minimalUser"`
-	AssignedUserIds []int  `json:"assignedUserIds"`
+	Id              int         `json:"id"`
+	Name            string      `json:"name"`
+	DateBegin       string      `json:"dateBegin"`
+	TimeBegin       string      `json:"timeBegin"`
+	LocationID      int         `json:"locationId"`
+	Location        string      `json:"location"`
+	MinimalUser     int         `json:"minimalUser"`
+	Status          string      `json:"status"`
+	Category        string      `json:"category"`
+	AssignedUserIds []int       `json:"assignedUserIds"`
+	Duties          []EventDuty `json:"duties"`
+}
+
+const (
+	EventStatusDraft     = "draft"
+	EventStatusPublished = "published"
+	EventStatusCancelled = "cancelled"
+)
+
+type EventCategory struct {
+	Category      string  `json:"category"`
+	Name          string  `json:"name"`
+	ServiceWeight float64 `json:"serviceWeight"`
+	MinExperience int     `json:"minExperience"`
+	Points        float64 `json:"points"`
+}
+
+// EventCategoryUpdate changes only the fields that are sent.
+type EventCategoryUpdate struct {
+	Name          *string  `json:"name"`
+	ServiceWeight *float64 `json:"serviceWeight"`
+	MinExperience *int     `json:"minExperience"`
+	Points        *float64 `json:"points"`
+}
+
+type EventStatusUpdate struct {
+	Status string `json:"status"`
+}
+
+type PublishRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type PlanPublication struct {
+	Id          int    `json:"id"`
+	DateFrom    string `json:"dateFrom"`
+	DateTo      string `json:"dateTo"`
+	PublishedBy string `json:"publishedBy"`
+	PublishedAt string `json:"publishedAt"`
 }
 
 type SingleBanDateUpdate struct {
@@ -33,6 +78,29 @@ type SingleWeekdayUpdate struct {
 }
 
 type Location struct {
-	Id   int    `json:"id"`
-	Name string `json:"name"`
+	Id      int    `json:"id"`
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	Notes   string `json:"notes"`
+	Active  bool   `json:"active"`
+}
+
+// LocationUpdate changes only the fields that are sent.
+type LocationUpdate struct {
+	Name    *string `json:"name"`
+	Address *string `json:"address"`
+	Notes   *string `json:"notes"`
+	Active  *bool   `json:"active"`
+}
+
+type Absence struct {
+	Id       int    `json:"id"`
+	DateFrom string `json:"dateFrom"`
+	DateTo   string `json:"dateTo"`
+	Reason   string `json:"reason"`
+}
+
+type AbsenceUpdate struct {
+	Add    []Absence `json:"add"`
+	Remove []int     `json:"remove"`
 }
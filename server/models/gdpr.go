@@ -0,0 +1,82 @@
+package models
+
+// ExportedAccount holds the columns of the user row that User leaves out.
+type ExportedAccount struct {
+	Phone              string `json:"phone"`
+	RegistrationStatus string `json:"registrationStatus"`
+	RegisteredAt       string `json:"registeredAt"`
+}
+
+type ExportedAssignment struct {
+	EventId        int    `json:"eventId"`
+	EventName      string `json:"eventName"`
+	DateBegin      string `json:"dateBegin"`
+	TimeBegin      string `json:"timeBegin"`
+	Location       string `json:"location"`
+	Status         string `json:"status"`
+	Attendance     string `json:"attendance"`
+	AttendanceNote string `json:"attendanceNote"`
+}
+
+type ExportedPreference struct {
+	UserId1 int    `json:"userId1"`
+	UserId2 int    `json:"userId2"`
+	Status  string `json:"status"`
+}
+
+type ExportedSurveyResponse struct {
+	SurveyId    int    `json:"surveyId"`
+	DateFrom    string `json:"dateFrom"`
+	DateTo      string `json:"dateTo"`
+	SubmittedAt string `json:"submittedAt"`
+}
+
+type ExportedLogin struct {
+	LoggedInAt string `json:"loggedInAt"`
+	Ip         string `json:"ip"`
+	UserAgent  string `json:"userAgent"`
+	Success    bool   `json:"success"`
+}
+
+type ExportedSession struct {
+	CreatedAt  string `json:"createdAt"`
+	LastUsedAt string `json:"lastUsedAt"`
+	ExpiresAt  string `json:"expiresAt"`
+	RevokedAt  string `json:"revokedAt"`
+	Ip         string `json:"ip"`
+	UserAgent  string `json:"userAgent"`
+}
+
+type ExportedPasswordReset struct {
+	CreatedAt string `json:"createdAt"`
+	ExpiresAt string `json:"expiresAt"`
+	UsedAt    string `json:"usedAt"`
+}
+
+type ExportedOIDCIdentity struct {
+	Issuer    string `json:"issuer"`
+	Subject   string `json:"subject"`
+	CreatedAt string `json:"createdAt"`
+}
+
+// UserDataExport holds everything that is stored about a single user.
+type UserDataExport struct {
+	ExportedAt        string                   `json:"exportedAt"`
+	User              User                     `json:"user"`
+	Account           ExportedAccount          `json:"account"`
+	Assignments       []ExportedAssignment     `json:"assignments"`
+	BanDates          []string                 `json:"banDates"`
+	Absences          []Absence                `json:"absences"`
+	Weekdays          []string                 `json:"weekdays"`
+	SurveyResponses   []ExportedSurveyResponse `json:"surveyResponses"`
+	BanChangeRequests []BanChangeRequest       `json:"banChangeRequests"`
+	Preferences       []ExportedPreference     `json:"preferences"`
+	Guardians         []UserSmall              `json:"guardians"`
+	Children          []UserSmall              `json:"children"`
+	Points            []PointsEntry            `json:"points"`
+	SwapRequests      []SwapRequest            `json:"swapRequests"`
+	LoginHistory      []ExportedLogin          `json:"loginHistory"`
+	Sessions          []ExportedSession        `json:"sessions"`
+	PasswordResets    []ExportedPasswordReset  `json:"passwordResets"`
+	OIDCIdentities    []ExportedOIDCIdentity   `json:"oidcIdentities"`
+}
@@ -0,0 +1,25 @@
+package models
+
+type UserImportRow struct {
+	Row             int      `json:"row"`
+	Firstname       string   `json:"firstname"`
+	Lastname        string   `json:"lastname"`
+	Username        string   `json:"username"`
+	RoleId          int      `json:"roleId"`
+	Active          int      `json:"active"`
+	Incense         int      `json:"incense"`
+	Experience      int      `json:"experience"`
+	Weekdays        []string `json:"weekdays"`
+	Errors          []string `json:"errors"`
+	Duplicate       string   `json:"duplicate,omitempty"`
+	CreatedId       int      `json:"createdId,omitempty"`
+	InitialPassword string   `json:"initialPassword,omitempty"`
+}
+
+type UserImportResult struct {
+	Committed bool            `json:"committed"`
+	Valid     int             `json:"valid"`
+	Invalid   int             `json:"invalid"`
+	Created   int             `json:"created"`
+	Rows      []UserImportRow `json:"rows"`
+}
@@ -0,0 +1,10 @@
+package models
+
+type ForgotPassword struct {
+	Login string `json:"login"`
+}
+
+type ResetPassword struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
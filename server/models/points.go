@@ -0,0 +1,31 @@
+package models
+
+type PointsEntry struct {
+	Id         int     `json:"id"`
+	Year       int     `json:"year"`
+	Points     float64 `json:"points"`
+	Source     string  `json:"source"`
+	EventId    *int    `json:"eventId"`
+	EventName  string  `json:"eventName"`
+	Reason     string  `json:"reason"`
+	CreatedAt  string  `json:"createdAt"`
+	ArchivedAt string  `json:"archivedAt"`
+}
+
+type PointsBalance struct {
+	UserId    int           `json:"userId"`
+	Firstname string        `json:"firstname"`
+	Lastname  string        `json:"lastname"`
+	Rank      int           `json:"rank"`
+	Points    float64       `json:"points"`
+	Entries   []PointsEntry `json:"entries,omitempty"`
+}
+
+type PointsAdjustment struct {
+	Points float64 `json:"points"`
+	Reason string  `json:"reason"`
+}
+
+type PointsArchive struct {
+	Year int `json:"year"`
+}
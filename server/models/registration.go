@@ -0,0 +1,34 @@
+package models
+
+const SettingRegistrationInviteCode = "registration_invite_code"
+
+const (
+	RegistrationApproved = "approved"
+	RegistrationPending  = "pending"
+)
+
+type Registration struct {
+	InviteCode string   `json:"inviteCode"`
+	Firstname  string   `json:"firstname"`
+	Lastname   string   `json:"lastname"`
+	Username   string   `json:"username"`
+	Password   string   `json:"password"`
+	Email      string   `json:"email"`
+	Phone      string   `json:"phone"`
+	Weekdays   []string `json:"weekdays"`
+}
+
+type PendingRegistration struct {
+	Id           int      `json:"id"`
+	Firstname    string   `json:"firstname"`
+	Lastname     string   `json:"lastname"`
+	Username     string   `json:"username"`
+	Email        string   `json:"email"`
+	Phone        string   `json:"phone"`
+	Weekdays     []string `json:"weekdays"`
+	RegisteredAt string   `json:"registeredAt"`
+}
+
+type RegistrationDecision struct {
+	Approve bool `json:"approve"`
+}
@@ -0,0 +1,38 @@
+package models
+
+const (
+	PermissionEventsWrite    = "events.write"
+	PermissionPlanAssign     = "plan.assign"
+	PermissionPlanPublish    = "plan.publish"
+	PermissionUsersManage    = "users.manage"
+	PermissionPdfExport      = "pdf.export"
+	PermissionRolesManage    = "roles.manage"
+	PermissionSettingsManage = "settings.manage"
+	PermissionAttendance     = "attendance.write"
+	PermissionPointsManage   = "points.manage"
+	PermissionApiKeysManage  = "apikeys.manage"
+)
+
+var AllPermissions = []string{
+	PermissionEventsWrite,
+	PermissionPlanAssign,
+	PermissionPlanPublish,
+	PermissionUsersManage,
+	PermissionPdfExport,
+	PermissionRolesManage,
+	PermissionSettingsManage,
+	PermissionAttendance,
+	PermissionPointsManage,
+	PermissionApiKeysManage,
+}
+
+type Role struct {
+	Id          int      `json:"id"`
+	Key         string   `json:"key"`
+	Name        string   `json:"name"`
+	Permissions []string `json:"permissions"`
+}
+
+type RolePermissionUpdate struct {
+	Permissions []string `json:"permissions"`
+}
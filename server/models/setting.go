@@ -0,0 +1,8 @@
+package models
+
+const SettingSwapRequiresApproval = "swap_requires_approval"
+
+type Setting struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
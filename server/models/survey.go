@@ -0,0 +1,26 @@
+package models
+
+type AvailabilitySurvey struct {
+	Id        int    `json:"id"`
+	DateFrom  string `json:"dateFrom"`
+	DateTo    string `json:"dateTo"`
+	Deadline  string `json:"deadline"`
+	Responded int    `json:"responded"`
+	Expected  int    `json:"expected"`
+	Closed    bool   `json:"closed"`
+}
+
+type BanChangeRequest struct {
+	Id        int    `json:"id"`
+	UserId    int    `json:"userId"`
+	Name      string `json:"name"`
+	SurveyId  int    `json:"surveyId"`
+	Kind      string `json:"kind"`
+	Add       bool   `json:"add"`
+	DateFrom  string `json:"dateFrom"`
+	DateTo    string `json:"dateTo"`
+	Reason    string `json:"reason"`
+	AbsenceId *int   `json:"absenceId"`
+	Status    string `json:"status"`
+	CreatedAt string `json:"createdAt"`
+}
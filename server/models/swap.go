@@ -0,0 +1,28 @@
+package models
+
+const (
+	SwapStatusOpen            = "open"
+	SwapStatusPendingApproval = "pending_approval"
+	SwapStatusCompleted       = "completed"
+	SwapStatusRejected        = "rejected"
+	SwapStatusCancelled       = "cancelled"
+)
+
+type SwapRequest struct {
+	Id         int    `json:"id"`
+	EventId    int    `json:"eventId"`
+	EventName  string `json:"eventName"`
+	DateBegin  string `json:"dateBegin"`
+	TimeBegin  string `json:"timeBegin"`
+	Location   string `json:"location"`
+	FromUserId int    `json:"fromUserId"`
+	FromName   string `json:"fromName"`
+	ToUserId   *int   `json:"toUserId"`
+	ToName     string `json:"toName"`
+	Status     string `json:"status"`
+	CreatedAt  string `json:"createdAt"`
+}
+
+type SwapOffer struct {
+	EventId int `json:"eventId"`
+}
@@ -0,0 +1,44 @@
+package models
+
+type EventDuty struct {
+	Duty  string `json:"duty"`
+	Count int    `json:"count"`
+}
+
+type EventTemplate struct {
+	Id            int         `json:"id"`
+	Name          string      `json:"name"`
+	EventName     string      `json:"eventName"`
+	TimeBegin     string      `json:"timeBegin"`
+	LocationID    int         `json:"locationId"`
+	Location      string      `json:"location"`
+	MinimalUser   int         `json:"minimalUser"`
+	IgnoreWeekday bool        `json:"ignoreWeekday"`
+	Category      string      `json:"category"`
+	Duties        []EventDuty `json:"duties"`
+}
+
+// EventTemplateUpdate changes only the fields that are sent. Duties are
+// replaced as a whole when they are sent and kept otherwise.
+type EventTemplateUpdate struct {
+	Name          *string      `json:"name"`
+	EventName     *string      `json:"eventName"`
+	TimeBegin     *string      `json:"timeBegin"`
+	LocationID    *int         `json:"locationId"`
+	MinimalUser   *int         `json:"minimalUser"`
+	IgnoreWeekday *bool        `json:"ignoreWeekday"`
+	Category      *string      `json:"category"`
+	Duties        *[]EventDuty `json:"duties"`
+}
+
+// EventFromTemplate creates one event per date. Every field that is set
+// overrides the value of the template.
+type EventFromTemplate struct {
+	Dates         []string `json:"dates"`
+	Name          *string  `json:"name"`
+	TimeBegin     *string  `json:"timeBegin"`
+	LocationID    *int     `json:"locationId"`
+	MinimalUser   *int     `json:"minimalUser"`
+	IgnoreWeekday *bool    `json:"ignoreWeekday"`
+	Category      *string  `json:"category"`
+}
@@ -1,19 +1,45 @@
 package models
 
 type UserHead struct {
-	Id     int    `json:"id"`
-	Name   string `json:"name"`
-	RoleId int    `json:"roleId"`
+	Id          int      `json:"id"`
+	Name        string   `json:"name"`
+	RoleId      int      `json:"roleId"`
+	Role        string   `json:"role"`
+	Permissions []string `json:"permissions"`
 }
 
 type User struct {
-	Id        int    `json:"id"`
-	Firstname string `json:"firstname"`
-	Lastname  string `json:"lastname"`
-	Username  string `json:"username"`
-	RoleId    int    `json:"roleId"`
-	Active    int    `json:"active"`
-	Incense   int    `json:"incense"`
+	Id         int    `json:"id"`
+	Firstname  string `json:"firstname"`
+	Lastname   string `json:"lastname"`
+	Username   string `json:"username"`
+	RoleId     int    `json:"roleId"`
+	Active     int    `json:"active"`
+	Incense    int    `json:"incense"`
+	Experience int    `json:"experience"`
+	Email      string `json:"email"`
+}
+
+// UserUpdate changes only the fields that are sent. Active, Incense and
+// Experience may only be changed by users with users.manage.
+type UserUpdate struct {
+	Firstname  *string `json:"firstname"`
+	Lastname   *string `json:"lastname"`
+	Active     *int    `json:"active"`
+	Incense    *int    `json:"incense"`
+	Experience *int    `json:"experience"`
+	Email      *string `json:"email"`
+}
+
+type NewUser struct {
+	Firstname  string `json:"firstname"`
+	Lastname   string `json:"lastname"`
+	Username   string `json:"username"`
+	RoleId     int    `json:"roleId"`
+	Password   string `json:"password"`
+	Incense    int    `json:"incense"`
+	Experience int    `json:"experience"`
+	Email      string `json:"email"`
 }
 
 type UserSmall struct {
@@ -43,3 +69,24 @@ type EventAssignmentOptionsResponse struct {
 	WeekdayKey []string                    `json:"weekdayKey"`
 	Options    []EventAssignmentUserOption `json:"options"`
 }
+
+type GuardianChildUpdate struct {
+	ChildId int  `json:"childId"`
+	Add     bool `json:"add"`
+}
+
+type ChildEvents struct {
+	Child  UserSmall `json:"child"`
+	Events []Event   `json:"events"`
+}
+
+type PreferredResponse struct {
+	OtherUserId int  `json:"otherUserId"`
+	Accept      bool `json:"accept"`
+}
+
+type PreferredOverview struct {
+	Accepted []UserSmall `json:"accepted"`
+	Outgoing []UserSmall `json:"outgoing"`
+	Incoming []UserSmall `json:"incoming"`
+}
@@ -0,0 +1,30 @@
+CREATE TABLE event_template (
+    id INT NOT NULL AUTO_INCREMENT,
+    name VARCHAR(255) NOT NULL,
+    event_name VARCHAR(255) NOT NULL,
+    time_begin TIME NOT NULL,
+    location_id INT NOT NULL,
+    minimalUser INT NOT NULL DEFAULT 0,
+    ignoreWeekday TINYINT(1) NOT NULL DEFAULT 0,
+    PRIMARY KEY (id),
+    FOREIGN KEY (location_id) REFERENCES location (id)
+);
+
+CREATE TABLE event_template_duty (
+    template_id INT NOT NULL,
+    duty VARCHAR(100) NOT NULL,
+    count INT NOT NULL DEFAULT 1,
+    PRIMARY KEY (template_id, duty),
+    FOREIGN KEY (template_id) REFERENCES event_template (id) ON DELETE CASCADE
+);
+
+ALTER TABLE event ADD COLUMN template_id INT NULL;
+ALTER TABLE event ADD FOREIGN KEY (template_id) REFERENCES event_template (id) ON DELETE SET NULL;
+
+CREATE TABLE event_duty (
+    event_id INT NOT NULL,
+    duty VARCHAR(100) NOT NULL,
+    count INT NOT NULL DEFAULT 1,
+    PRIMARY KEY (event_id, duty),
+    FOREIGN KEY (event_id) REFERENCES event (id) ON DELETE CASCADE
+);
@@ -0,0 +1,3 @@
+ALTER TABLE location ADD COLUMN address VARCHAR(255) NULL;
+ALTER TABLE location ADD COLUMN notes TEXT NULL;
+ALTER TABLE location ADD COLUMN active TINYINT(1) NOT NULL DEFAULT 1;
@@ -0,0 +1,16 @@
+ALTER TABLE event ADD COLUMN status ENUM('draft', 'published', 'cancelled') NOT NULL DEFAULT 'draft';
+ALTER TABLE plan ADD COLUMN status ENUM('draft', 'published') NOT NULL DEFAULT 'draft';
+
+-- everything that exists so far was already visible to the servers
+UPDATE event SET status = 'published';
+UPDATE plan SET status = 'published';
+
+CREATE TABLE plan_publication (
+    id INT NOT NULL AUTO_INCREMENT,
+    date_from DATE NOT NULL,
+    date_to DATE NOT NULL,
+    published_by INT NOT NULL,
+    published_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    FOREIGN KEY (published_by) REFERENCES user (id)
+);
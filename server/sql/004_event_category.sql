@@ -0,0 +1,23 @@
+CREATE TABLE event_category (
+    category VARCHAR(20) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    service_weight DECIMAL(4, 2) NOT NULL DEFAULT 1,
+    min_experience INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (category)
+);
+
+INSERT INTO event_category (category, name, service_weight, min_experience) VALUES
+    ('mass', 'Messe', 1, 0),
+    ('feast_day', 'Hochfest', 2, 0),
+    ('funeral', 'Beerdigung', 1, 0),
+    ('wedding', 'Hochzeit', 1, 2),
+    ('rehearsal', 'Probe', 0, 0),
+    ('other', 'Sonstiges', 1, 0);
+
+ALTER TABLE event ADD COLUMN category VARCHAR(20) NOT NULL DEFAULT 'mass';
+ALTER TABLE event ADD FOREIGN KEY (category) REFERENCES event_category (category);
+
+ALTER TABLE event_template ADD COLUMN category VARCHAR(20) NOT NULL DEFAULT 'mass';
+ALTER TABLE event_template ADD FOREIGN KEY (category) REFERENCES event_category (category);
+
+ALTER TABLE user ADD COLUMN experience INT NOT NULL DEFAULT 0;
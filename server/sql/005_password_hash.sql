@@ -0,0 +1,2 @@
+-- bcrypt hashes are 60 characters long; plaintext rows are re-hashed on the next login
+ALTER TABLE user MODIFY COLUMN password VARCHAR(255) NOT NULL;
@@ -0,0 +1,6 @@
+ALTER TABLE user ADD UNIQUE INDEX user_username_unique (username);
+
+-- deleting a user keeps the record of who published a plan period
+ALTER TABLE plan_publication MODIFY COLUMN published_by INT NULL;
+ALTER TABLE plan_publication DROP FOREIGN KEY plan_publication_ibfk_1;
+ALTER TABLE plan_publication ADD FOREIGN KEY (published_by) REFERENCES user (id) ON DELETE SET NULL;
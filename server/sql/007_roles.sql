@@ -0,0 +1,39 @@
+CREATE TABLE role (
+    id INT NOT NULL,
+    role_key VARCHAR(30) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    PRIMARY KEY (id),
+    UNIQUE INDEX role_key_unique (role_key)
+);
+
+-- the ids match the numeric role levels that were used before
+INSERT INTO role (id, role_key, name) VALUES
+    (1, 'server', 'Ministrant'),
+    (2, 'planner', 'Planer'),
+    (3, 'admin', 'Administrator'),
+    (4, 'group_leader', 'Gruppenleiter');
+
+CREATE TABLE role_permission (
+    role_id INT NOT NULL,
+    permission VARCHAR(50) NOT NULL,
+    PRIMARY KEY (role_id, permission),
+    FOREIGN KEY (role_id) REFERENCES role (id) ON DELETE CASCADE
+);
+
+INSERT INTO role_permission (role_id, permission) VALUES
+    (1, 'pdf.export'),
+    (4, 'pdf.export'),
+    (4, 'plan.assign'),
+    (2, 'pdf.export'),
+    (2, 'plan.assign'),
+    (2, 'plan.publish'),
+    (2, 'events.write'),
+    (2, 'users.manage'),
+    (3, 'pdf.export'),
+    (3, 'plan.assign'),
+    (3, 'plan.publish'),
+    (3, 'events.write'),
+    (3, 'users.manage'),
+    (3, 'roles.manage');
+
+ALTER TABLE user ADD FOREIGN KEY (role_id) REFERENCES role (id);
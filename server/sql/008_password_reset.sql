@@ -0,0 +1,11 @@
+ALTER TABLE user ADD COLUMN email VARCHAR(255) NULL;
+
+CREATE TABLE password_reset (
+    token_hash CHAR(64) NOT NULL,
+    user_id INT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at DATETIME NOT NULL,
+    used_at DATETIME NULL,
+    PRIMARY KEY (token_hash),
+    FOREIGN KEY (user_id) REFERENCES user (id) ON DELETE CASCADE
+);
@@ -0,0 +1,9 @@
+INSERT INTO role (id, role_key, name) VALUES (5, 'guardian', 'Erziehungsberechtigter');
+
+CREATE TABLE guardian_child (
+    guardian_id INT NOT NULL,
+    child_id INT NOT NULL,
+    PRIMARY KEY (guardian_id, child_id),
+    FOREIGN KEY (guardian_id) REFERENCES user (id) ON DELETE CASCADE,
+    FOREIGN KEY (child_id) REFERENCES user (id) ON DELETE CASCADE
+);
@@ -0,0 +1,25 @@
+CREATE TABLE setting (
+    setting_key VARCHAR(50) NOT NULL,
+    value VARCHAR(255) NOT NULL,
+    PRIMARY KEY (setting_key)
+);
+
+INSERT INTO setting (setting_key, value) VALUES ('swap_requires_approval', '0');
+
+INSERT INTO role_permission (role_id, permission) VALUES (3, 'settings.manage');
+
+CREATE TABLE swap_request (
+    id INT NOT NULL AUTO_INCREMENT,
+    event_id INT NOT NULL,
+    from_user_id INT NOT NULL,
+    to_user_id INT NULL,
+    status ENUM('open', 'pending_approval', 'completed', 'rejected', 'cancelled') NOT NULL DEFAULT 'open',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    decided_by INT NULL,
+    decided_at DATETIME NULL,
+    PRIMARY KEY (id),
+    FOREIGN KEY (event_id) REFERENCES event (id) ON DELETE CASCADE,
+    FOREIGN KEY (from_user_id) REFERENCES user (id) ON DELETE CASCADE,
+    FOREIGN KEY (to_user_id) REFERENCES user (id) ON DELETE CASCADE,
+    FOREIGN KEY (decided_by) REFERENCES user (id) ON DELETE SET NULL
+);
@@ -0,0 +1,10 @@
+CREATE TABLE absence (
+    id INT NOT NULL AUTO_INCREMENT,
+    user_id INT NOT NULL,
+    date_from DATE NOT NULL,
+    date_to DATE NOT NULL,
+    reason VARCHAR(255) NULL,
+    PRIMARY KEY (id),
+    INDEX absence_user_dates (user_id, date_from, date_to),
+    FOREIGN KEY (user_id) REFERENCES user (id) ON DELETE CASCADE
+);
@@ -0,0 +1,39 @@
+CREATE TABLE availability_survey (
+    id INT NOT NULL AUTO_INCREMENT,
+    date_from DATE NOT NULL,
+    date_to DATE NOT NULL,
+    deadline DATE NOT NULL,
+    created_by INT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    FOREIGN KEY (created_by) REFERENCES user (id) ON DELETE SET NULL
+);
+
+CREATE TABLE availability_response (
+    survey_id INT NOT NULL,
+    user_id INT NOT NULL,
+    submitted_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (survey_id, user_id),
+    FOREIGN KEY (survey_id) REFERENCES availability_survey (id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES user (id) ON DELETE CASCADE
+);
+
+CREATE TABLE ban_change_request (
+    id INT NOT NULL AUTO_INCREMENT,
+    user_id INT NOT NULL,
+    survey_id INT NOT NULL,
+    kind ENUM('ban', 'absence') NOT NULL,
+    add_change TINYINT(1) NOT NULL,
+    date_from DATE NULL,
+    date_to DATE NULL,
+    reason VARCHAR(255) NULL,
+    absence_id INT NULL,
+    status ENUM('pending', 'approved', 'rejected') NOT NULL DEFAULT 'pending',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    decided_by INT NULL,
+    decided_at DATETIME NULL,
+    PRIMARY KEY (id),
+    FOREIGN KEY (user_id) REFERENCES user (id) ON DELETE CASCADE,
+    FOREIGN KEY (survey_id) REFERENCES availability_survey (id) ON DELETE CASCADE,
+    FOREIGN KEY (decided_by) REFERENCES user (id) ON DELETE SET NULL
+);
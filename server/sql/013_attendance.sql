@@ -0,0 +1,10 @@
+ALTER TABLE plan ADD COLUMN attendance ENUM('present', 'excused', 'no_show') NULL;
+ALTER TABLE plan ADD COLUMN attendance_note VARCHAR(255) NULL;
+ALTER TABLE plan ADD COLUMN attendance_by INT NULL;
+ALTER TABLE plan ADD COLUMN attendance_at DATETIME NULL;
+ALTER TABLE plan ADD FOREIGN KEY (attendance_by) REFERENCES user (id) ON DELETE SET NULL;
+
+INSERT INTO role_permission (role_id, permission) VALUES
+    (2, 'attendance.write'),
+    (3, 'attendance.write'),
+    (4, 'attendance.write');
@@ -0,0 +1,27 @@
+ALTER TABLE event_category ADD COLUMN points DECIMAL(5, 2) NOT NULL DEFAULT 1;
+
+-- feast days give extra points, rehearsals give none
+UPDATE event_category SET points = 2 WHERE category = 'feast_day';
+UPDATE event_category SET points = 0 WHERE category = 'rehearsal';
+
+CREATE TABLE points_entry (
+    id INT NOT NULL AUTO_INCREMENT,
+    user_id INT NOT NULL,
+    year INT NOT NULL,
+    points DECIMAL(6, 2) NOT NULL,
+    source ENUM('attendance', 'manual') NOT NULL,
+    event_id INT NULL,
+    reason VARCHAR(255) NULL,
+    created_by INT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    archived_at DATETIME NULL,
+    PRIMARY KEY (id),
+    INDEX points_entry_user_year (user_id, year),
+    FOREIGN KEY (user_id) REFERENCES user (id) ON DELETE CASCADE,
+    FOREIGN KEY (event_id) REFERENCES event (id) ON DELETE SET NULL,
+    FOREIGN KEY (created_by) REFERENCES user (id) ON DELETE SET NULL
+);
+
+INSERT INTO role_permission (role_id, permission) VALUES
+    (2, 'points.manage'),
+    (3, 'points.manage');
@@ -0,0 +1,11 @@
+ALTER TABLE preference_together ADD COLUMN status ENUM('pending', 'accepted') NOT NULL DEFAULT 'pending';
+ALTER TABLE preference_together ADD COLUMN created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP;
+
+-- pairs that both sides entered are already mutual, all others have to be confirmed
+UPDATE preference_together p
+INNER JOIN preference_together r ON r.user_id_1 = p.user_id_2 AND r.user_id_2 = p.user_id_1
+SET p.status = 'accepted';
+
+DELETE p FROM preference_together p
+INNER JOIN preference_together r ON r.user_id_1 = p.user_id_2 AND r.user_id_2 = p.user_id_1
+WHERE p.user_id_1 > p.user_id_2;
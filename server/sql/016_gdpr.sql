@@ -0,0 +1,13 @@
+CREATE TABLE login_history (
+    id INT NOT NULL AUTO_INCREMENT,
+    user_id INT NOT NULL,
+    logged_in_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ip VARCHAR(45) NOT NULL,
+    user_agent VARCHAR(255) NULL,
+    success TINYINT(1) NOT NULL,
+    PRIMARY KEY (id),
+    INDEX login_history_user (user_id, logged_in_at),
+    FOREIGN KEY (user_id) REFERENCES user (id) ON DELETE CASCADE
+);
+
+ALTER TABLE user ADD COLUMN erased_at DATETIME NULL;
@@ -0,0 +1,7 @@
+ALTER TABLE user
+    ADD COLUMN registration_status ENUM('approved', 'pending') NOT NULL DEFAULT 'approved',
+    ADD COLUMN phone VARCHAR(50) NULL,
+    ADD COLUMN registered_at DATETIME NULL;
+
+-- An empty invite code disables the public sign-up.
+INSERT INTO setting (setting_key, value) VALUES ('registration_invite_code', '');
@@ -0,0 +1,17 @@
+CREATE TABLE session (
+    id INT NOT NULL AUTO_INCREMENT,
+    user_id INT NOT NULL,
+    refresh_hash CHAR(64) NOT NULL,
+    previous_hash CHAR(64) NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_used_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at DATETIME NOT NULL,
+    revoked_at DATETIME NULL,
+    ip VARCHAR(45) NOT NULL,
+    user_agent VARCHAR(255) NULL,
+    PRIMARY KEY (id),
+    UNIQUE KEY session_refresh (refresh_hash),
+    INDEX session_previous (previous_hash),
+    INDEX session_user (user_id),
+    FOREIGN KEY (user_id) REFERENCES user (id) ON DELETE CASCADE
+);
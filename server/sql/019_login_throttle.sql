@@ -0,0 +1,9 @@
+CREATE TABLE login_failure (
+    id INT NOT NULL AUTO_INCREMENT,
+    username VARCHAR(100) NOT NULL,
+    ip VARCHAR(45) NOT NULL,
+    failed_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    INDEX login_failure_username (username, failed_at),
+    INDEX login_failure_ip (ip, failed_at)
+);
@@ -0,0 +1,23 @@
+CREATE TABLE api_key (
+    id INT NOT NULL AUTO_INCREMENT,
+    name VARCHAR(100) NOT NULL,
+    key_prefix VARCHAR(12) NOT NULL,
+    key_hash CHAR(64) NOT NULL,
+    created_by INT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at DATE NULL,
+    last_used_at DATETIME NULL,
+    revoked_at DATETIME NULL,
+    PRIMARY KEY (id),
+    UNIQUE KEY api_key_hash (key_hash),
+    FOREIGN KEY (created_by) REFERENCES user (id) ON DELETE SET NULL
+);
+
+CREATE TABLE api_key_scope (
+    api_key_id INT NOT NULL,
+    scope VARCHAR(50) NOT NULL,
+    PRIMARY KEY (api_key_id, scope),
+    FOREIGN KEY (api_key_id) REFERENCES api_key (id) ON DELETE CASCADE
+);
+
+INSERT INTO role_permission (role_id, permission) VALUES (3, 'apikeys.manage');
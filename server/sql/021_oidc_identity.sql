@@ -0,0 +1,9 @@
+CREATE TABLE oidc_identity (
+    issuer VARCHAR(255) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    user_id INT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (issuer, subject),
+    UNIQUE KEY oidc_identity_user (issuer, user_id),
+    FOREIGN KEY (user_id) REFERENCES user (id) ON DELETE CASCADE
+);
@@ -0,0 +1,6 @@
+-- the failure table also throttles password reset mails and registrations
+ALTER TABLE login_failure ADD COLUMN kind VARCHAR(20) NOT NULL DEFAULT 'login' AFTER id;
+ALTER TABLE login_failure DROP INDEX login_failure_username;
+ALTER TABLE login_failure DROP INDEX login_failure_ip;
+ALTER TABLE login_failure ADD INDEX login_failure_username (kind, username, failed_at);
+ALTER TABLE login_failure ADD INDEX login_failure_ip (kind, ip, failed_at);
@@ -1,16 +1,23 @@
 
 import React from 'react';
+import { Routes, Route } from 'react-router-dom';
 import App from './components/App';
 import Authentication from './components/Authentication';
+import PasswordReset from './components/PasswordReset';
 import useToken from "./hooks/useToken";
 
 function TokenContainer() {
-	const {token, removeToken, setToken} = useToken();
+	const {token, removeToken, setToken, loginError} = useToken();
+
+	const loggedOut = !token && token !== "" && token !== undefined;
 
 	return (
 		<div>
-			{!token && token !== "" && token !== undefined ?
-				<Authentication setToken={setToken} /> : <App token={token} removeToken={removeToken}/>}
+			<Routes>
+				<Route path="/reset" element={<PasswordReset />} />
+				<Route path="*" element={loggedOut ?
+					<Authentication setToken={setToken} loginError={loginError} /> : <App token={token} removeToken={removeToken}/>} />
+			</Routes>
 		</div>
 	);
 }
@@ -19,6 +19,7 @@ function App(props) {
   const [userId, setUserId] = useState();
   const [editUserId, setEditUserId] = useState(null);
   const [roleId, setRoleId] = useState();
+  const [permissions, setPermissions] = useState([]);
   const [initials, setInitials] = useState();
   const [userModalOpen, setUserModalOpen] = useState(false);
 
@@ -33,6 +34,7 @@ function App(props) {
       setInitials(res.data.name.split(' ').map(word => word[0]).join(''));
       setUserId(res.data.id);
       setRoleId(res.data.roleId);
+      setPermissions(res.data.permissions || []);
     });
     // eslint-disable-next-line react-hooks/exhaustive-deps
   }, []);
@@ -46,14 +48,22 @@ function App(props) {
     },
   ];
 
-  if (roleId === 2 || roleId === 3) {
+  const canManageUsers = permissions.includes('users.manage');
+  const canAssign = permissions.includes('plan.assign');
+
+  if (canManageUsers) {
     menuItems.push(
       {
         key: '2',
         icon: <DatabaseOutlined />,
         label: !isCompactMasterData ? <Link to="/stammdaten">Stammdaten</Link> : null,
         onClick: () => navigate('/stammdaten')
-      },
+      }
+    );
+  }
+
+  if (canAssign) {
+    menuItems.push(
       {
         key: '3',
         icon: <AppstoreOutlined />,
@@ -98,23 +108,22 @@ function App(props) {
           <Content style={{ padding: '20px' }}>
             <Routes>
               <Route path="/" element={<Home userId={userId} token={props.token} />} />
-              {(roleId === 2 || roleId === 3) && (
-                <>
-                  <Route
-                    path="/stammdaten"
-                    element={
-                      <Stammdaten
-                        token={props.token}
-                        onEditUser={(id) => {
-                          setEditUserId(id);
-                          setUserModalOpen(true);
-                        }}
-                      />
-                    }
-                  />
-
-                  <Route path="/einteilung" element={<Einteilung token={props.token}/>} />
-                </>
+              {canManageUsers && (
+                <Route
+                  path="/stammdaten"
+                  element={
+                    <Stammdaten
+                      token={props.token}
+                      onEditUser={(id) => {
+                        setEditUserId(id);
+                        setUserModalOpen(true);
+                      }}
+                    />
+                  }
+                />
+              )}
+              {canAssign && (
+                <Route path="/einteilung" element={<Einteilung token={props.token}/>} />
               )}
             </Routes>
           </Content>
@@ -125,6 +134,7 @@ function App(props) {
       <UserEditModal
         userId={editUserId ?? userId}
         roleId={roleId}
+        canManage={canManageUsers}
         token={props.token}
         open={userModalOpen}
         onClose={() => {
@@ -1,8 +1,8 @@
-import React, { useState } from 'react';
+import React, { useEffect, useState } from 'react';
 import { Form, Input, Button, Checkbox, Card, Row, Col, Typography, App as AntdApp } from 'antd';
 import { UserOutlined, LockOutlined } from '@ant-design/icons';
 import { useNavigate } from 'react-router-dom';
-import { doPostRequest } from '../helper/RequestHelper';
+import { doPostRequest, doGetRequest, oidcLoginUrl } from '../helper/RequestHelper';
 import './Authentication.css';
 
 const { Title } = Typography;
@@ -11,18 +11,32 @@ function Authentication(props) {
 	const { message } = AntdApp.useApp();
 	const navigate = useNavigate();
 	const [loading, setLoading] = useState(false);
+	const [oidcEnabled, setOidcEnabled] = useState(false);
+
+	useEffect(() => {
+		doGetRequest("oidc/config").then((res) => setOidcEnabled(res.data.enabled), () => setOidcEnabled(false));
+		if (props.loginError) {
+			message.error(props.loginError);
+		}
+		// eslint-disable-next-line react-hooks/exhaustive-deps
+	}, []);
 
 	function handleLogin(values) {
 		setLoading(true);
 		const params = { username: values.username, password: values.password };
 		doPostRequest("login", params).then((response) => {
 			setLoading(false);
-			props.setToken(response.data.accessToken, values.remember);
+			props.setToken(response.data.accessToken, values.remember, response.data.refreshToken);
 			navigate("/")
 		}, error => {
 			setLoading(false);
 			if (error.response.status === 401) {
 				message.error('Benutzername oder Passwort falsch!');
+			} else if (error.response.status === 429) {
+				const minutes = Math.ceil((error.response.data.retryAfter || 60) / 60);
+				message.error('Zu viele Fehlversuche, bitte in ' + minutes + ' Minute(n) erneut versuchen.');
+			} else if (error.response.data && error.response.data.error) {
+				message.error(error.response.data.error);
 			}
 			return error;
 		});
@@ -77,10 +91,20 @@ function Authentication(props) {
 							</Form.Item>
 
 							<Form.Item style={{ textAlign: 'right' }}>
+								<Button type="link" onClick={() => navigate("/reset")}>
+									Passwort vergessen?
+								</Button>
 								<Button type="primary" htmlType="submit" className="login-form-button" loading={loading}>
 									Log in
 								</Button>
 							</Form.Item>
+							{oidcEnabled && (
+								<Form.Item style={{ textAlign: 'center' }}>
+									<Button block href={oidcLoginUrl}>
+										Mit Bistumskonto anmelden
+									</Button>
+								</Form.Item>
+							)}
 						</Form>
 					</Card>
 				</Col>
@@ -20,6 +20,7 @@ import { DownloadOutlined, PlusOutlined } from "@ant-design/icons";
 import dayjs from "dayjs";
 import {
   doGetRequestAuth,
+  doGetRequestBlobAuth,
   doPatchRequestAuth,
   doPutRequestAuth,
 } from "../helper/RequestHelper";
@@ -306,9 +307,10 @@ const renderUserOptionLabel = (u) => {
     const from = dayjs(dateRange[0]).format("YYYY-MM-DD");
     const to = dayjs(dateRange[1]).format("YYYY-MM-DD");
 
-    const url = `/pdf/events?from=${from}&to=${to}`;
-
-    window.open(url, "_blank");
+    doGetRequestBlobAuth(`events/pdf?from=${from}&to=${to}`, token).then((res) => {
+      const url = URL.createObjectURL(res.data);
+      window.open(url, "_blank");
+    });
   };
 
   const handleAutoAssign = (eventId) => {
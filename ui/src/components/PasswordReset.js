@@ -0,0 +1,133 @@
+import React, { useState } from 'react';
+import { Form, Input, Button, Card, Row, Col, Typography, App as AntdApp } from 'antd';
+import { UserOutlined, LockOutlined } from '@ant-design/icons';
+import { useNavigate, useSearchParams } from 'react-router-dom';
+import { doPostRequest } from '../helper/RequestHelper';
+import './Authentication.css';
+
+const { Title, Paragraph } = Typography;
+
+// PasswordReset asks for a reset link without a token and sets the new
+// password with the token from the link of the mail.
+function PasswordReset() {
+	const { message } = AntdApp.useApp();
+	const navigate = useNavigate();
+	const [searchParams] = useSearchParams();
+	const token = searchParams.get("token");
+	const [loading, setLoading] = useState(false);
+	const [requested, setRequested] = useState(false);
+
+	function handleError(error) {
+		setLoading(false);
+		if (error.response && error.response.status === 429) {
+			const minutes = Math.ceil((error.response.data.retryAfter || 60) / 60);
+			message.error('Zu viele Anfragen, bitte in ' + minutes + ' Minute(n) erneut versuchen.');
+		} else if (error.response && error.response.data && error.response.data.error) {
+			message.error(error.response.data.error);
+		}
+	}
+
+	function handleRequest(values) {
+		setLoading(true);
+		doPostRequest("password/forgot", { login: values.login }).then(() => {
+			setLoading(false);
+			setRequested(true);
+		}, handleError);
+	}
+
+	function handleReset(values) {
+		setLoading(true);
+		doPostRequest("password/reset", { token: token, password: values.password }).then(() => {
+			setLoading(false);
+			message.success("Passwort geändert, du kannst dich jetzt anmelden");
+			navigate("/");
+		}, handleError);
+	}
+
+	return (
+		<div style={{
+			height: '100vh',
+			backgroundImage: 'url(background_login.webp)',
+			backgroundSize: 'cover',
+			backgroundPosition: 'center',
+			display: 'flex',
+			justifyContent: 'center',
+			alignItems: 'center'
+		}}>
+			<Row justify="center" align="middle">
+				<Col>
+					<Card style={{ minWidth: 300, maxWidth: 400, boxShadow: '0 4px 8px rgba(0, 0, 0, 0.2)' }}>
+						<Title level={2} style={{ textAlign: 'center' }}>Passwort zurücksetzen</Title>
+						{token ? (
+							<Form name="password_reset" onFinish={handleReset}>
+								<Form.Item
+									name="password"
+									rules={[{ required: true, message: 'Bitte neues Passwort angeben!' }]}
+								>
+									<Input.Password
+										className="login-input"
+										prefix={<LockOutlined className="site-form-item-icon" />}
+										placeholder="Neues Passwort"
+									/>
+								</Form.Item>
+								<Form.Item
+									name="confirm"
+									dependencies={['password']}
+									rules={[
+										{ required: true, message: 'Bitte Passwort wiederholen!' },
+										({ getFieldValue }) => ({
+											validator(_, value) {
+												return !value || getFieldValue('password') === value
+													? Promise.resolve()
+													: Promise.reject(new Error('Die Passwörter stimmen nicht überein'));
+											}
+										})
+									]}
+								>
+									<Input.Password
+										className="login-input"
+										prefix={<LockOutlined className="site-form-item-icon" />}
+										placeholder="Passwort wiederholen"
+									/>
+								</Form.Item>
+								<Form.Item style={{ textAlign: 'right' }}>
+									<Button type="primary" htmlType="submit" loading={loading}>
+										Passwort setzen
+									</Button>
+								</Form.Item>
+							</Form>
+						) : requested ? (
+							<Paragraph>
+								Falls es zu diesem Benutzernamen oder dieser E-Mail-Adresse ein Konto gibt, haben wir dir einen Link geschickt.
+								Er ist eine Stunde gültig.
+							</Paragraph>
+						) : (
+							<Form name="password_forgot" onFinish={handleRequest}>
+								<Form.Item
+									name="login"
+									rules={[{ required: true, message: 'Bitte Benutzernamen oder E-Mail angeben!' }]}
+								>
+									<Input
+										className="login-input"
+										prefix={<UserOutlined className="site-form-item-icon" />}
+										placeholder="Benutzername oder E-Mail"
+									/>
+								</Form.Item>
+								<Form.Item style={{ textAlign: 'right' }}>
+									<Button type="primary" htmlType="submit" loading={loading}>
+										Link anfordern
+									</Button>
+								</Form.Item>
+							</Form>
+						)}
+						<Button type="link" block onClick={() => navigate("/")}>
+							Zurück zur Anmeldung
+						</Button>
+					</Card>
+				</Col>
+			</Row>
+		</div>
+	);
+}
+
+export default PasswordReset;
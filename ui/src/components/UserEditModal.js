@@ -10,7 +10,7 @@ import UserBanDates from "./UserBanDates";
 import UserPreferredWeekdays from "./UserPreferredWeekdays";
 import UserPreferredPartners from "./UserPreferredPartners";
 
-export default function UserEditModal({ userId, token, open, onClose, onSaved, roleId }) {
+export default function UserEditModal({ userId, token, open, onClose, onSaved, roleId, canManage }) {
     const [loading, setLoading] = useState(true);
     const [user, setUser] = useState();
     const [passwordModalOpen, setPasswordModalOpen] = useState(false);
@@ -54,12 +54,12 @@ export default function UserEditModal({ userId, token, open, onClose, onSaved, r
 
         const payload = {
             firstname: values.firstname,
-            lastname: values.lastname,
-            username: values.username,
-            roleId: values.roleId,
-            active: values.active ? 1 : 0,
-            incense: values.incense ? 1 : 0
+            lastname: values.lastname
         };
+        if (canManage) {
+            payload.active = values.active ? 1 : 0;
+            payload.incense = values.incense ? 1 : 0;
+        }
 
         await doPatchRequestAuth(`user/${userId}`, payload, token);
         message.success("Änderungen gespeichert");
@@ -88,6 +88,7 @@ export default function UserEditModal({ userId, token, open, onClose, onSaved, r
                                         handleSave={handleSave}
                                         onOpenPassword={() => setPasswordModalOpen(true)}
                                         roleId={roleId}
+                                        canManage={canManage}
                                     />
                                 )
                             },
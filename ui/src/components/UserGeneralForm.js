@@ -1,6 +1,6 @@
 import { Form, Input, Switch, Button } from "antd";
 
-export default function UserGeneralForm({ form, handleSave, onOpenPassword, roleId }) {
+export default function UserGeneralForm({ form, handleSave, onOpenPassword, roleId, canManage }) {
     return (
         <Form layout="vertical" form={form}>
             <Form.Item label="Vorname" name="firstname" rules={[{ required: true }]}>
@@ -20,11 +20,11 @@ export default function UserGeneralForm({ form, handleSave, onOpenPassword, role
             </Form.Item>
 
             <Form.Item label="Weihrauch" name="incense" valuePropName="checked">
-                <Switch />
+                <Switch disabled={!canManage}/>
             </Form.Item>
 
             <Form.Item label="Aktiv" name="active" valuePropName="checked">
-                <Switch disabled={roleId == 1}/>
+                <Switch disabled={roleId == 1 || !canManage}/>
             </Form.Item>
             <div style={{ display: "flex", justifyContent: "space-between", marginTop: 12 }}>
                 <Button type="default" onClick={onOpenPassword}>
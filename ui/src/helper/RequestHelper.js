@@ -3,6 +3,49 @@ import axios from "axios";
 export const baseUrl = window.location.hostname + ":" + window.location.port
 const url = "http://localhost:8080/"
 
+export const TOKEN_REFRESHED_EVENT = "tokenRefreshed"
+export const SESSION_ENDED_EVENT = "sessionEnded"
+
+let refreshing = null
+
+function tokenStorage() {
+	return localStorage.getItem('refreshToken') ? localStorage : sessionStorage
+}
+
+// Exchanges the stored refresh token for a new token pair. Parallel requests
+// that fail at the same time share one refresh call.
+function refreshAccessToken() {
+	if (!refreshing) {
+		const storage = tokenStorage()
+		const refreshToken = storage.getItem('refreshToken')
+		refreshing = (refreshToken ? axios.post(url + "token/refresh", { refreshToken }) : Promise.reject())
+			.then((res) => {
+				storage.setItem('jwtToken', res.data.accessToken)
+				storage.setItem('refreshToken', res.data.refreshToken)
+				window.dispatchEvent(new Event(TOKEN_REFRESHED_EVENT))
+				return res.data.accessToken
+			}, (error) => {
+				window.dispatchEvent(new Event(SESSION_ENDED_EVENT))
+				throw error
+			})
+			.finally(() => { refreshing = null })
+	}
+	return refreshing
+}
+
+axios.interceptors.response.use(undefined, (error) => {
+	const config = error.config
+	const isAuthCall = config && (config.url.endsWith("login") || config.url.endsWith("token/refresh"))
+	if (error.response && error.response.status === 401 && config && config.headers.Authorization && !config._retried && !isAuthCall) {
+		config._retried = true
+		return refreshAccessToken().then((accessToken) => {
+			config.headers.Authorization = 'Bearer ' + accessToken
+			return axios(config)
+		}, () => Promise.reject(error))
+	}
+	return Promise.reject(error)
+})
+
 export async function doPostRequest(path, param) {
 	return axios.post(url+path, param)
 }
@@ -11,14 +54,20 @@ export async function doPostRequestAuth(path, param, auth) {
 	return axios.post(url+path, param, {headers: {Authorization: 'Bearer ' + auth}})
 }
 
-// export async function doGetRequest(path) {
-// 	return axios.get(url+path)
-// }
+export async function doGetRequest(path) {
+	return axios.get(url+path)
+}
+
+export const oidcLoginUrl = url + "oidc/login"
 
 export async function doGetRequestBlob(path) {
 	return axios.get(url+path, { responseType: 'blob' })
 }
 
+export async function doGetRequestBlobAuth(path, auth) {
+	return axios.get(url+path, { responseType: 'blob', headers: {Authorization: 'Bearer ' + auth}})
+}
+
 export async function doGetRequestAuth(path, auth) {
 	return axios.get(url+path, {headers: {Authorization: 'Bearer ' + auth}})
 }
@@ -1,8 +1,28 @@
-import {useState} from 'react';
+import {useEffect, useState} from 'react';
+import {doPostRequestAuth, TOKEN_REFRESHED_EVENT, SESSION_ENDED_EVENT} from '../helper/RequestHelper';
 
 // Hooks used for user authentication with Tokens
 function useToken() {
 
+  // after a login through OpenID Connect the server redirects back with the
+  // tokens in the URL fragment
+  function takeTokenFromFragment() {
+    const fragment = new URLSearchParams(window.location.hash.substring(1));
+    const accessToken = fragment.get('accessToken');
+    if (accessToken) {
+      localStorage.removeItem('jwtToken');
+      localStorage.removeItem('refreshToken');
+      sessionStorage.setItem('jwtToken', accessToken);
+      sessionStorage.setItem('refreshToken', fragment.get('refreshToken'));
+    }
+    if (accessToken || fragment.get('loginError')) {
+      window.history.replaceState(null, '', window.location.pathname + window.location.search);
+    }
+    return fragment.get('loginError');
+  }
+
+  const [loginError] = useState(takeTokenFromFragment);
+
   function getToken() {
     const userTokenLocal = localStorage.getItem('jwtToken');
     const userTokenSession = sessionStorage.getItem('jwtToken');
@@ -11,25 +31,47 @@ function useToken() {
 
   const [token, setToken] = useState(getToken());
 
-  function saveToken(userToken, remember) {
-    if (remember) {
-      localStorage.setItem('jwtToken', userToken);
-    } else {
-      sessionStorage.setItem('jwtToken', userToken);
+  // the request helper refreshes expired access tokens on its own
+  useEffect(() => {
+    const onRefreshed = () => setToken(getToken());
+    const onEnded = () => clearStorage();
+    window.addEventListener(TOKEN_REFRESHED_EVENT, onRefreshed);
+    window.addEventListener(SESSION_ENDED_EVENT, onEnded);
+    return () => {
+      window.removeEventListener(TOKEN_REFRESHED_EVENT, onRefreshed);
+      window.removeEventListener(SESSION_ENDED_EVENT, onEnded);
+    };
+  }, []);
+
+  function saveToken(userToken, remember, refreshToken) {
+    const storage = remember ? localStorage : sessionStorage;
+    storage.setItem('jwtToken', userToken);
+    if (refreshToken) {
+      storage.setItem('refreshToken', refreshToken);
     }
     setToken(userToken);
   };
 
-  function removeToken() {
+  function clearStorage() {
     localStorage.removeItem("jwtToken");
     sessionStorage.removeItem("jwtToken");
+    localStorage.removeItem("refreshToken");
+    sessionStorage.removeItem("refreshToken");
     setToken(null);
   }
+
+  function removeToken() {
+    if (token) {
+      doPostRequestAuth("logout", {}, token).catch(() => {});
+    }
+    clearStorage();
+  }
   return {
     setToken: saveToken,
     token,
-    removeToken
+    removeToken,
+    loginError
   };
 }
 
-export default useToken;
\ No newline at end of file
+export default useToken;